@@ -1,93 +0,0 @@
-package gee
-
-import (
-	"log"
-	"net/http"
-	"strings"
-)
-
-type HandlerFunc func(*Context)
-
-// Engine implement ServerHTTP
-type Engine struct {
-	*RouterGroup
-	router *router
-	groups []*RouterGroup
-}
-
-// New 创建一个Engine
-func New() *Engine {
-	engine := &Engine{router: newRouter()}
-	engine.RouterGroup = &RouterGroup{engine: engine}
-	engine.groups = []*RouterGroup{engine.RouterGroup}
-	return engine
-}
-
-func (e *Engine) addRoute(method, pattern string, handler HandlerFunc) {
-	log.Printf("Engine Route %4s - %s", method, pattern)
-	e.router.addRoute(method, pattern, handler)
-}
-
-func (e *Engine) GET(pattern string, handler HandlerFunc) {
-	e.addRoute("GET", pattern, handler)
-}
-
-func (e *Engine) POST(pattern string, handler HandlerFunc) {
-	e.addRoute("POST", pattern, handler)
-}
-
-func (e *Engine) Run(addr string) error {
-	return http.ListenAndServe(addr, e)
-}
-
-func (e *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	var middlewares []HandlerFunc
-	for _, group := range e.groups {
-		if strings.HasPrefix(req.URL.Path, group.prefix) {
-			middlewares = append(middlewares, group.middlewares...)
-		}
-	}
-	c := newContext(w, req)
-	c.handlers = middlewares
-	e.router.handle(c)
-}
-
-type RouterGroup struct {
-	prefix      string
-	middlewares []HandlerFunc // support middleware
-	parent      *RouterGroup  // support nesting
-	engine      *Engine       // all groups share one instance
-}
-
-// Group is defined to create a new RouterGroup
-// remember all groups share the same Engine instance
-func (group *RouterGroup) Group(prefix string) *RouterGroup {
-	engine := group.engine
-	newGroup := &RouterGroup{
-		prefix: group.prefix + prefix,
-		parent: group,
-		engine: engine,
-	}
-	engine.groups = append(engine.groups, newGroup)
-	return newGroup
-}
-
-func (group *RouterGroup) addRoute(method string, comp string, handler HandlerFunc) {
-	pattern := group.prefix + comp
-	log.Printf("GroupRoute %4s - %s", method, pattern)
-	group.engine.router.addRoute(method, pattern, handler)
-}
-
-// GET defines the method to add GET request
-func (group *RouterGroup) GET(pattern string, handler HandlerFunc) {
-	group.addRoute("GET", pattern, handler)
-}
-
-// POST defines the method to add POST request
-func (group *RouterGroup) POST(pattern string, handler HandlerFunc) {
-	group.addRoute("POST", pattern, handler)
-}
-
-func (group *RouterGroup) Use(middlewares ...HandlerFunc) {
-	group.middlewares = append(group.middlewares, middlewares...)
-}
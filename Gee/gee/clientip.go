@@ -0,0 +1,80 @@
+package gee
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// SetTrustedProxies sets the proxies (IP or CIDR) whose X-Forwarded-For and X-Real-IP headers are trusted
+// by Context.ClientIP. No proxy is trusted by default, so the headers are ignored.
+func (e *Engine) SetTrustedProxies(proxies []string) error {
+	cidrs := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return fmt.Errorf("gee: invalid trusted proxy %q", proxy)
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			proxy = fmt.Sprintf("%s/%d", proxy, bits)
+		}
+		_, cidr, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("gee: invalid trusted proxy %q: %v", proxy, err)
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	e.trustedCIDRs = cidrs
+	return nil
+}
+
+func (e *Engine) isTrustedProxy(ip net.IP) bool {
+	for _, cidr := range e.trustedCIDRs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the real client IP. When the direct peer is a trusted proxy, X-Forwarded-For is
+// walked from right to left and the first untrusted address is returned, then X-Real-IP is tried.
+func (c *Context) ClientIP() string {
+	remoteIP := remoteIP(c.Req.RemoteAddr)
+	if remoteIP == nil {
+		return ""
+	}
+	if c.engine == nil || !c.engine.isTrustedProxy(remoteIP) {
+		return remoteIP.String()
+	}
+
+	if xff := c.Req.Header.Get("X-Forwarded-For"); xff != "" {
+		items := strings.Split(xff, ",")
+		for i := len(items) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(items[i]))
+			if ip == nil {
+				break
+			}
+			// 最左侧的地址即使属于受信代理也只能作为结果返回
+			if i == 0 || !c.engine.isTrustedProxy(ip) {
+				return ip.String()
+			}
+		}
+	}
+	if ip := net.ParseIP(strings.TrimSpace(c.Req.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return remoteIP.String()
+}
+
+func remoteIP(remoteAddr string) net.IP {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(remoteAddr))
+	if err != nil {
+		host = remoteAddr
+	}
+	return net.ParseIP(host)
+}
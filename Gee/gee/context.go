@@ -0,0 +1,234 @@
+package gee
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type H map[string]interface{}
+
+type Context struct {
+	// origin objects
+	Writer http.ResponseWriter
+	Req    *http.Request
+	// req info
+	Path    string
+	Method  string
+	Params  map[string]string
+	Pattern string // Pattern 匹配到的路由，例如 /p/:lang/doc，未匹配时为空
+	// response info
+	StatusCode int
+	// middleware
+	handlers []HandlerFunc
+	index    int
+	// engine pointer
+	engine *Engine
+	// queryCache 和 formCache 缓存解析后的参数，避免每次调用都重新解析
+	queryCache url.Values
+	formCache  url.Values
+	// Keys 在中间件和 handler 之间传递的数据
+	Keys map[string]interface{}
+}
+
+func (c *Context) Param(key string) string {
+	return c.Params[key]
+}
+
+func newContext(w http.ResponseWriter, req *http.Request) *Context {
+	return &Context{
+		Writer: w,
+		Req:    req,
+		Path:   req.URL.Path,
+		Method: req.Method,
+		index:  -1,
+	}
+}
+
+func (c *Context) Next() {
+	c.index++
+	s := len(c.handlers)
+	for ; c.index < s; c.index++ {
+		c.handlers[c.index](c)
+	}
+}
+
+// Set stores a value in c.Keys for the handlers of this request
+func (c *Context) Set(key string, value interface{}) {
+	if c.Keys == nil {
+		c.Keys = make(map[string]interface{})
+	}
+	c.Keys[key] = value
+}
+
+// Get returns the value stored by Set, it also reports whether the key exists
+func (c *Context) Get(key string) (value interface{}, exists bool) {
+	value, exists = c.Keys[key]
+	return
+}
+
+// Abort prevents the remaining handlers from being called, it does not stop the current handler
+func (c *Context) Abort() {
+	c.index = len(c.handlers)
+}
+
+// IsAborted reports whether the remaining handlers are skipped
+func (c *Context) IsAborted() bool {
+	return c.index >= len(c.handlers)
+}
+
+// ParamInt returns the value of the route parameter as an int
+func (c *Context) ParamInt(key string) (int, error) {
+	v, err := strconv.Atoi(c.Params[key])
+	if err != nil {
+		return 0, fmt.Errorf("gee: invalid param %s: %v", key, err)
+	}
+	return v, nil
+}
+
+func (c *Context) initFormCache() {
+	if c.formCache == nil {
+		// 与 Req.FormValue 一致，解析失败时忽略错误
+		_ = c.Req.ParseMultipartForm(32 << 20)
+		c.formCache = c.Req.Form
+		if c.formCache == nil {
+			c.formCache = url.Values{}
+		}
+	}
+}
+
+// PostForm returns the first value of the form key, query parameters are included as Req.FormValue does
+func (c *Context) PostForm(key string) string {
+	v, _ := c.GetPostForm(key)
+	return v
+}
+
+// GetPostForm is like PostForm, it also reports whether the key exists
+func (c *Context) GetPostForm(key string) (string, bool) {
+	c.initFormCache()
+	if values, ok := c.formCache[key]; ok && len(values) > 0 {
+		return values[0], true
+	}
+	return "", false
+}
+
+func (c *Context) initQueryCache() {
+	if c.queryCache == nil {
+		c.queryCache = c.Req.URL.Query()
+	}
+}
+
+// Query returns the first value of the url query key, it returns "" if the key does not exist
+func (c *Context) Query(key string) string {
+	v, _ := c.GetQuery(key)
+	return v
+}
+
+// DefaultQuery returns the first value of the url query key, or defaultValue if the key does not exist
+func (c *Context) DefaultQuery(key, defaultValue string) string {
+	if v, ok := c.GetQuery(key); ok {
+		return v
+	}
+	return defaultValue
+}
+
+// GetQuery is like Query, it also reports whether the key exists
+func (c *Context) GetQuery(key string) (string, bool) {
+	if values, ok := c.GetQueryArray(key); ok {
+		return values[0], true
+	}
+	return "", false
+}
+
+// QueryArray returns all values of the url query key
+func (c *Context) QueryArray(key string) []string {
+	values, _ := c.GetQueryArray(key)
+	return values
+}
+
+// GetQueryArray is like QueryArray, it also reports whether the key has at least one value
+func (c *Context) GetQueryArray(key string) ([]string, bool) {
+	c.initQueryCache()
+	values, ok := c.queryCache[key]
+	return values, ok && len(values) > 0
+}
+
+// QueryMap returns a map for the url query key, eg. ?ids[a]=1&ids[b]=2 => QueryMap("ids") = {a: 1, b: 2}
+func (c *Context) QueryMap(key string) map[string]string {
+	c.initQueryCache()
+	m := make(map[string]string)
+	for k, values := range c.queryCache {
+		if i := strings.IndexByte(k, '['); i == len(key) && k[:i] == key && len(values) > 0 {
+			if j := strings.IndexByte(k[i+1:], ']'); j >= 0 {
+				m[k[i+1:i+1+j]] = values[0]
+			}
+		}
+	}
+	return m
+}
+
+// QueryInt returns the first value of the url query key as an int
+func (c *Context) QueryInt(key string) (int, error) {
+	v, err := strconv.Atoi(c.Query(key))
+	if err != nil {
+		return 0, fmt.Errorf("gee: invalid query %s: %v", key, err)
+	}
+	return v, nil
+}
+
+// QueryBool returns the first value of the url query key as a bool, accepting the values of strconv.ParseBool
+func (c *Context) QueryBool(key string) (bool, error) {
+	v, err := strconv.ParseBool(c.Query(key))
+	if err != nil {
+		return false, fmt.Errorf("gee: invalid query %s: %v", key, err)
+	}
+	return v, nil
+}
+
+// QueryDuration returns the first value of the url query key as a time.Duration, eg. 1.5s, 300ms
+func (c *Context) QueryDuration(key string) (time.Duration, error) {
+	v, err := time.ParseDuration(c.Query(key))
+	if err != nil {
+		return 0, fmt.Errorf("gee: invalid query %s: %v", key, err)
+	}
+	return v, nil
+}
+
+func (c *Context) Status(code int) {
+	c.StatusCode = code
+	c.Writer.WriteHeader(code)
+}
+
+func (c *Context) SetHeader(key, value string) {
+	c.Writer.Header().Set(key, value)
+}
+
+func (c *Context) String(code int, format string, values ...interface{}) {
+	c.SetHeader("Content-Type", "text/plain")
+	c.Status(code)
+	c.Writer.Write([]byte(fmt.Sprintf(format, values...)))
+}
+
+func (c *Context) JSON(code int, obj interface{}) {
+	c.SetHeader("Content-Type", "application/json")
+	c.Status(code)
+	encoder := json.NewEncoder(c.Writer)
+	if err := encoder.Encode(obj); err != nil {
+		http.Error(c.Writer, err.Error(), 500)
+	}
+}
+
+func (c *Context) Data(code int, data []byte) {
+	c.Status(code)
+	c.Writer.Write(data)
+}
+
+func (c *Context) HTML(code int, html string) {
+	c.SetHeader("Content-Type", "text/html")
+	c.Status(code)
+	c.Writer.Write([]byte(html))
+}
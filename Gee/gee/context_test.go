@@ -0,0 +1,78 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestContextQuery(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/?a=1&a=2&b=true&d=1.5s&m[x]=1&m[y]=2&mx=3", nil)
+	c := newContext(httptest.NewRecorder(), req)
+
+	if c.Query("a") != "1" || !reflect.DeepEqual(c.QueryArray("a"), []string{"1", "2"}) {
+		t.Fatal("failed to get query a")
+	}
+	if _, ok := c.GetQuery("none"); ok || c.DefaultQuery("none", "default") != "default" {
+		t.Fatal("missing query should use default value")
+	}
+	if !reflect.DeepEqual(c.QueryMap("m"), map[string]string{"x": "1", "y": "2"}) {
+		t.Fatal("failed to get query map, got", c.QueryMap("m"))
+	}
+	if v, err := c.QueryInt("a"); err != nil || v != 1 {
+		t.Fatal("failed to get int query", err)
+	}
+	if v, err := c.QueryBool("b"); err != nil || !v {
+		t.Fatal("failed to get bool query", err)
+	}
+	if v, err := c.QueryDuration("d"); err != nil || v != 1500*time.Millisecond {
+		t.Fatal("failed to get duration query", err)
+	}
+	if _, err := c.QueryInt("b"); err == nil {
+		t.Fatal("expect an error for invalid int query")
+	}
+
+	// the parsed query is cached per request
+	req.URL.RawQuery = "a=3"
+	if c.Query("a") != "1" {
+		t.Fatal("query values should be cached")
+	}
+}
+
+func TestContextClientIP(t *testing.T) {
+	e := New()
+	if err := e.SetTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetTrustedProxies([]string{"invalid"}); err == nil {
+		t.Fatal("expect an error for invalid proxy")
+	}
+
+	cases := []struct {
+		remote, xff, realIP, expect string
+	}{
+		{"1.2.3.4:80", "5.6.7.8", "", "1.2.3.4"},              // untrusted peer, headers ignored
+		{"10.0.0.1:80", "5.6.7.8, 10.0.0.2", "", "5.6.7.8"},   // skip trusted hops
+		{"192.168.1.1:80", "9.9.9.9, 5.6.7.8", "", "5.6.7.8"}, // first untrusted from the right
+		{"10.0.0.1:80", "", "5.6.7.8", "5.6.7.8"},             // fallback to X-Real-IP
+		{"10.0.0.1:80", "10.0.0.3, 10.0.0.2", "", "10.0.0.3"}, // all trusted, leftmost wins
+		{"[::1]:80", "", "", "::1"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tc.remote
+		if tc.xff != "" {
+			req.Header.Set("X-Forwarded-For", tc.xff)
+		}
+		if tc.realIP != "" {
+			req.Header.Set("X-Real-IP", tc.realIP)
+		}
+		c := newContext(httptest.NewRecorder(), req)
+		c.engine = e
+		if ip := c.ClientIP(); ip != tc.expect {
+			t.Fatalf("remote %s xff %q: expect %s, got %s", tc.remote, tc.xff, tc.expect, ip)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Package csrf 实现基于 double-submit cookie 的 CSRF 防护中间件
+// 1. 服务端生成随机 secret 保存在 cookie 中，页面通过 csrf.Token(c) 取得 token 放入表单或请求头
+// 2. 非安全方法(POST、PUT、DELETE 等)必须在请求头或表单中携带与 cookie 匹配的 token
+// 3. 每次 Token(c) 返回的 token 都使用新的随机掩码，避免 BREACH 之类的压缩侧信道泄露 secret
+// 4. HTTPS 请求额外校验 Origin/Referer 必须与当前站点同源
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/MarkRepo/Gee/Gee/gee"
+)
+
+const (
+	tokenLength = 32
+	secretKey   = "csrf.secret" // secretKey 保存本次请求使用的 secret，保证同一请求内 Token(c) 一致
+)
+
+var (
+	ErrNoToken   = errors.New("csrf: token not found in request")
+	ErrBadToken  = errors.New("csrf: token invalid")
+	ErrBadOrigin = errors.New("csrf: origin does not match")
+	ErrNoReferer = errors.New("csrf: referer not supplied")
+)
+
+// Options CSRF 中间件配置
+type Options struct {
+	CookieName string        // CookieName 保存 secret 的 cookie，默认 _csrf
+	CookiePath string        // CookiePath 默认 /
+	MaxAge     time.Duration // MaxAge cookie 有效期，默认 12 小时
+	SameSite   http.SameSite // SameSite 默认 http.SameSiteLaxMode
+
+	HeaderName string // HeaderName 携带 token 的请求头，默认 X-CSRF-Token
+	FieldName  string // FieldName 携带 token 的表单字段，默认 csrf_token
+
+	TrustedOrigins []string                  // TrustedOrigins 额外允许的来源 host，例如 app.example.com
+	Exempt         []string                  // Exempt 不做校验的路由，与 c.Pattern 比较，例如 /api/webhook/:id
+	Skip           func(*gee.Context) bool   // Skip 返回 true 时不做校验
+	ErrorHandler   func(*gee.Context, error) // ErrorHandler 校验失败时调用，默认返回 403
+}
+
+// New returns the CSRF middleware
+func New(opts ...Options) gee.HandlerFunc {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.CookieName == "" {
+		opt.CookieName = "_csrf"
+	}
+	if opt.CookiePath == "" {
+		opt.CookiePath = "/"
+	}
+	if opt.MaxAge == 0 {
+		opt.MaxAge = 12 * time.Hour
+	}
+	if opt.SameSite == 0 {
+		opt.SameSite = http.SameSiteLaxMode
+	}
+	if opt.HeaderName == "" {
+		opt.HeaderName = "X-CSRF-Token"
+	}
+	if opt.FieldName == "" {
+		opt.FieldName = "csrf_token"
+	}
+	if opt.ErrorHandler == nil {
+		opt.ErrorHandler = func(c *gee.Context, err error) {
+			c.String(http.StatusForbidden, "%s\n", err)
+		}
+	}
+	exempt := make(map[string]bool, len(opt.Exempt))
+	for _, pattern := range opt.Exempt {
+		exempt[pattern] = true
+	}
+
+	return func(c *gee.Context) {
+		secret := readSecret(c, opt.CookieName)
+		if secret == nil {
+			secret = make([]byte, tokenLength)
+			if _, err := rand.Read(secret); err != nil {
+				c.String(http.StatusInternalServerError, "csrf: %v\n", err)
+				c.Abort()
+				return
+			}
+			http.SetCookie(c.Writer, &http.Cookie{
+				Name:     opt.CookieName,
+				Value:    base64.RawURLEncoding.EncodeToString(secret),
+				Path:     opt.CookiePath,
+				MaxAge:   int(opt.MaxAge / time.Second),
+				Secure:   c.Req.TLS != nil,
+				HttpOnly: true,
+				SameSite: opt.SameSite,
+			})
+		}
+		c.Set(secretKey, secret)
+		// 缓存等中间件根据 Vary 区分不同用户的响应
+		c.Writer.Header().Add("Vary", "Cookie")
+
+		if isSafeMethod(c.Method) || exempt[c.Pattern] || (opt.Skip != nil && opt.Skip(c)) {
+			c.Next()
+			return
+		}
+		if err := verify(c, secret, &opt); err != nil {
+			opt.ErrorHandler(c, err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// Token returns a masked token for the current request, put it in a form field or the request header.
+// It returns "" if the CSRF middleware is not used.
+func Token(c *gee.Context) string {
+	v, ok := c.Get(secretKey)
+	if !ok {
+		return ""
+	}
+	return mask(v.([]byte))
+}
+
+func verify(c *gee.Context, secret []byte, opt *Options) error {
+	if c.Req.TLS != nil {
+		if err := checkOrigin(c.Req, opt.TrustedOrigins); err != nil {
+			return err
+		}
+	}
+	token := c.Req.Header.Get(opt.HeaderName)
+	if token == "" {
+		token = c.PostForm(opt.FieldName)
+	}
+	if token == "" {
+		return ErrNoToken
+	}
+	if !equal(unmask(token), secret) {
+		return ErrBadToken
+	}
+	return nil
+}
+
+// checkOrigin 校验 Origin，没有 Origin 时校验 Referer
+func checkOrigin(req *http.Request, trusted []string) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		referer := req.Header.Get("Referer")
+		if referer == "" {
+			return ErrNoReferer
+		}
+		origin = referer
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme != "https" {
+		return ErrBadOrigin
+	}
+	if strings.EqualFold(u.Host, req.Host) {
+		return nil
+	}
+	for _, host := range trusted {
+		if strings.EqualFold(u.Host, host) {
+			return nil
+		}
+	}
+	return ErrBadOrigin
+}
+
+func readSecret(c *gee.Context, name string) []byte {
+	cookie, err := c.Req.Cookie(name)
+	if err != nil {
+		return nil
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil || len(secret) != tokenLength {
+		return nil
+	}
+	return secret
+}
+
+// mask 返回 base64(pad || pad xor secret)
+func mask(secret []byte) string {
+	token := make([]byte, tokenLength*2)
+	pad := token[:tokenLength]
+	if _, err := rand.Read(pad); err != nil {
+		panic("csrf: " + err.Error())
+	}
+	for i := range secret {
+		token[tokenLength+i] = pad[i] ^ secret[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(token)
+}
+
+func unmask(token string) []byte {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) != tokenLength*2 {
+		return nil
+	}
+	secret := make([]byte, tokenLength)
+	for i := range secret {
+		secret[i] = b[i] ^ b[tokenLength+i]
+	}
+	return secret
+}
+
+func equal(a, b []byte) bool {
+	return len(a) == len(b) && subtle.ConstantTimeCompare(a, b) == 1
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package csrf
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/MarkRepo/Gee/Gee/gee"
+)
+
+func newEngine() *gee.Engine {
+	r := gee.New()
+	r.Use(New(Options{Exempt: []string{"/webhook"}}))
+	r.GET("/form", func(c *gee.Context) {
+		c.String(http.StatusOK, "%s", Token(c))
+	})
+	r.POST("/form", func(c *gee.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	r.POST("/webhook", func(c *gee.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	return r
+}
+
+func post(r *gee.Engine, path string, cookie *http.Cookie, form url.Values, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCSRF(t *testing.T) {
+	r := newEngine()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/form", nil))
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || w.Body.Len() == 0 {
+		t.Fatal("expect csrf cookie and token")
+	}
+	cookie, token := cookies[0], w.Body.String()
+
+	if w := post(r, "/form", cookie, nil, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("request without token should be rejected, got %d", w.Code)
+	}
+	if w := post(r, "/form", cookie, url.Values{"csrf_token": {token}}, nil); w.Code != http.StatusOK {
+		t.Fatalf("form token should be accepted, got %d", w.Code)
+	}
+	if w := post(r, "/form", cookie, nil, http.Header{"X-Csrf-Token": {token}}); w.Code != http.StatusOK {
+		t.Fatalf("header token should be accepted, got %d", w.Code)
+	}
+	if w := post(r, "/form", nil, url.Values{"csrf_token": {token}}, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("token without cookie should be rejected, got %d", w.Code)
+	}
+	if w := post(r, "/webhook", nil, nil, nil); w.Code != http.StatusOK {
+		t.Fatalf("exempt route should not be checked, got %d", w.Code)
+	}
+}
+
+func TestCSRFOrigin(t *testing.T) {
+	r := newEngine()
+	secret := make([]byte, tokenLength)
+	cookie := &http.Cookie{Name: "_csrf", Value: "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"}
+	header := http.Header{"X-Csrf-Token": {mask(secret)}}
+
+	for origin, code := range map[string]int{
+		"":                    http.StatusForbidden,
+		"https://example.com": http.StatusOK,
+		"https://evil.com":    http.StatusForbidden,
+		"http://example.com":  http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "https://example.com/form", nil)
+		req.TLS = &tls.ConnectionState{}
+		req.AddCookie(cookie)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != code {
+			t.Fatalf("origin %q: expect %d, got %d", origin, code, w.Code)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package gee
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ETag 缓冲 GET/HEAD 请求的 200 响应，根据响应体生成 ETag，客户端缓存仍然有效时返回 304
+// weak 为 true 时生成弱 ETag(W/"...")，适用于内容语义相同但字节可能不同的响应(例如经过压缩)
+// handler 自己设置了 ETag 时不会覆盖；handler 调用 Flush 后不再缓冲，响应直接写出
+func ETag(weak bool) HandlerFunc {
+	return func(c *Context) {
+		if c.Method != http.MethodGet && c.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+		w := &etagWriter{ResponseWrapper: ResponseWrapper{c.Writer}}
+		c.Writer = w
+		defer func() { c.Writer = w.ResponseWriter }()
+		c.Next()
+		if w.passthrough {
+			return
+		}
+
+		code := w.status()
+		header := w.Header()
+		if code == http.StatusOK && header.Get("ETag") == "" {
+			sum := sha1.Sum(w.buf.Bytes())
+			tag := `"` + hex.EncodeToString(sum[:]) + `"`
+			if weak {
+				tag = "W/" + tag
+			}
+			header.Set("ETag", tag)
+		}
+		c.Writer = w.ResponseWriter
+		if code == http.StatusOK && c.Fresh() {
+			c.notModified()
+			return
+		}
+		w.ResponseWriter.WriteHeader(code)
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+}
+
+// Fresh reports whether the client copy is still current according to If-None-Match and If-Modified-Since,
+// comparing them with the ETag and Last-Modified response headers set before calling Fresh:
+//
+//	c.SetHeader("ETag", version)
+//	if c.Fresh() {
+//		c.Status(http.StatusNotModified)
+//		return
+//	}
+func (c *Context) Fresh() bool {
+	if c.Method != http.MethodGet && c.Method != http.MethodHead {
+		return false
+	}
+	if strings.Contains(c.Req.Header.Get("Cache-Control"), "no-cache") {
+		return false
+	}
+	header := c.Writer.Header()
+	// 同时存在时 If-None-Match 优先，见 RFC 7232 6
+	if inm := c.Req.Header.Get("If-None-Match"); inm != "" {
+		return etagMatch(inm, header.Get("ETag"))
+	}
+	if ims := c.Req.Header.Get("If-Modified-Since"); ims != "" {
+		since, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		modified, err := http.ParseTime(header.Get("Last-Modified"))
+		if err != nil {
+			return false
+		}
+		return !modified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+// notModified 写出 304 响应，去掉与响应体相关的头
+func (c *Context) notModified() {
+	header := c.Writer.Header()
+	header.Del("Content-Type")
+	header.Del("Content-Length")
+	c.Status(http.StatusNotModified)
+}
+
+// etagMatch 使用弱比较判断 If-None-Match 中是否有与 etag 匹配的值
+func etagMatch(inm, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	if strings.TrimSpace(inm) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, v := range strings.Split(inm, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(v), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// etagWriter 缓冲响应，Flush 或 Hijack 之后切换为直接写出
+type etagWriter struct {
+	ResponseWrapper
+	code        int
+	buf         bytes.Buffer
+	passthrough bool
+}
+
+func (w *etagWriter) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
+
+func (w *etagWriter) WriteHeader(code int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.buf.Write(b)
+}
+
+func (w *etagWriter) Flush() {
+	if !w.passthrough {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(w.status())
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+	w.ResponseWrapper.Flush()
+}
+
+func (w *etagWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.ResponseWrapper.Hijack()
+	if err == nil {
+		w.passthrough = true
+	}
+	return conn, rw, err
+}
@@ -0,0 +1,61 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestETag(t *testing.T) {
+	r := New()
+	r.Use(ETag(false))
+	r.GET("/data", func(c *Context) {
+		c.JSON(http.StatusOK, H{"name": "gee"})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/data", nil))
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" || w.Body.String() != "{\"name\":\"gee\"}\n" {
+		t.Fatalf("unexpected response %d %q etag %q", w.Code, w.Body.String(), etag)
+	}
+
+	for inm, code := range map[string]int{etag: http.StatusNotModified, "W/" + etag: http.StatusNotModified, `"other"`: http.StatusOK} {
+		req := httptest.NewRequest(http.MethodGet, "/data", nil)
+		req.Header.Set("If-None-Match", inm)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != code || (code == http.StatusNotModified && w.Body.Len() != 0) {
+			t.Fatalf("If-None-Match %s: expect %d, got %d %q", inm, code, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestContextFresh(t *testing.T) {
+	modified := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := New()
+	var calls int
+	r.GET("/doc", func(c *Context) {
+		c.SetHeader("Last-Modified", modified.Format(http.TimeFormat))
+		if c.Fresh() {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		calls++
+		c.String(http.StatusOK, "doc")
+	})
+
+	for since, code := range map[time.Time]int{modified: http.StatusNotModified, modified.Add(-time.Hour): http.StatusOK} {
+		req := httptest.NewRequest(http.MethodGet, "/doc", nil)
+		req.Header.Set("If-Modified-Since", since.Format(http.TimeFormat))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != code {
+			t.Fatalf("If-Modified-Since %v: expect %d, got %d", since, code, w.Code)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expect expensive work done once, got %d", calls)
+	}
+}
@@ -0,0 +1,215 @@
+package gee
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type HandlerFunc func(*Context)
+
+// Engine implement ServerHTTP
+type Engine struct {
+	*RouterGroup              // RouterGroup 不属于任何路由表，通过它注册的路由和分组作用于当前路由表
+	current      atomic.Value // current 当前使用的路由表 *Table，见 Engine.Swap
+	middlewares  atomic.Value // middlewares 对所有路由表生效的中间件 []HandlerFunc，修改时整体替换，见 Engine.Use
+	mu           sync.Mutex   // mu 保护路由表的修改和 middlewares 的替换，见 Table.live
+
+	trustedCIDRs []*net.IPNet // trustedCIDRs 受信任的代理地址，见 Context.ClientIP
+}
+
+// RouteInfo represents a registered route, the documentation fields are optional
+type RouteInfo struct {
+	Host     string // Host 路由所属的 host 模式，为空表示 Engine 自身的路由
+	Method   string
+	Pattern  string
+	Summary  string
+	Request  interface{} // Request 请求绑定结构体，字段通过 path/query/header/form/json tag 描述参数
+	Response interface{} // Response 响应结构体
+	Mounted  bool        // Mounted 路由由 Mount 注册，处理的是挂载的 http.Handler，见 RouterGroup.Mount
+
+	disabled int32 // disabled 为 1 时路由被禁用，见 RouteInfo.Disable
+}
+
+// Doc annotates the route with a summary, the request binding struct and the response struct
+func (r *RouteInfo) Doc(summary string, request, response interface{}) *RouteInfo {
+	r.Summary = summary
+	r.Request = request
+	r.Response = response
+	return r
+}
+
+// New 创建一个Engine
+func New() *Engine {
+	engine := &Engine{}
+	engine.RouterGroup = &RouterGroup{engine: engine}
+	engine.current.Store(newTable(engine))
+	engine.middlewares.Store([]HandlerFunc(nil))
+	return engine
+}
+
+func (e *Engine) addRoute(method, pattern string, handler HandlerFunc) *RouteInfo {
+	log.Printf("Engine Route %4s - %s", method, pattern)
+	return e.table().addRoute(nil, method, pattern, handler)
+}
+
+// Use adds middlewares applied to all routes of every table, including tables swapped in later.
+// It is safe to call while requests are being served
+func (e *Engine) Use(middlewares ...HandlerFunc) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	old := e.middlewares.Load().([]HandlerFunc)
+	e.middlewares.Store(append(append([]HandlerFunc(nil), old...), middlewares...))
+}
+
+func (e *Engine) GET(pattern string, handler HandlerFunc) *RouteInfo {
+	return e.addRoute("GET", pattern, handler)
+}
+
+func (e *Engine) POST(pattern string, handler HandlerFunc) *RouteInfo {
+	return e.addRoute("POST", pattern, handler)
+}
+
+// Routes returns all routes of the current table in registration order
+func (e *Engine) Routes() []*RouteInfo {
+	return e.table().routes
+}
+
+// Route returns the route of the current table with method and pattern, see Table.Route
+func (e *Engine) Route(method, pattern string) *RouteInfo {
+	return e.table().Route(method, pattern)
+}
+
+// Run 启动 HTTP 服务，同时支持 h2c(明文 HTTP/2)，见 Engine.H2CHandler
+func (e *Engine) Run(addr string) error {
+	return http.ListenAndServe(addr, e.H2CHandler())
+}
+
+func (e *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	middlewares := append([]HandlerFunc(nil), e.middlewares.Load().([]HandlerFunc)...)
+	// 整个请求只使用一次读取到的路由表，Swap 不影响正在处理的请求
+	t := e.table()
+	t.setLive()
+	r, groups := t.router, t.groups
+	h, hostParams := t.matchHost(req.Host)
+	if h != nil {
+		// 根分组的中间件对所有 host 生效
+		r, groups = h.router, h.groups
+		middlewares = append(middlewares, t.middlewares...)
+	}
+	for _, group := range groups {
+		if strings.HasPrefix(req.URL.Path, group.prefix) {
+			middlewares = append(middlewares, group.middlewares...)
+		}
+	}
+	c := newContext(w, req)
+	c.handlers = middlewares
+	c.engine = e
+	c.Params = hostParams
+	r.handle(c)
+}
+
+type RouterGroup struct {
+	prefix      string
+	middlewares []HandlerFunc // support middleware
+	parent      *RouterGroup  // support nesting
+	engine      *Engine       // all groups share one instance
+	table       *Table        // table 分组所属的路由表，Engine 自身的根分组为 nil，见 resolve
+	host        *host         // host 为 nil 时路由注册到 Engine 自身的 router
+}
+
+// Group is defined to create a new RouterGroup
+// remember all groups share the same Engine instance
+func (group *RouterGroup) Group(prefix string) *RouterGroup {
+	group = group.resolve()
+	newGroup := &RouterGroup{
+		prefix: group.prefix + prefix,
+		parent: group,
+		engine: group.engine,
+		table:  group.table,
+		host:   group.host,
+	}
+	group.table.modify(func() {
+		if group.host != nil {
+			group.host.groups = append(group.host.groups, newGroup)
+		} else {
+			group.table.groups = append(group.table.groups, newGroup)
+		}
+	})
+	return newGroup
+}
+
+// resolve Engine 自身的根分组不属于任何路由表，返回当前路由表的根分组
+func (group *RouterGroup) resolve() *RouterGroup {
+	if group.table == nil {
+		return group.engine.table().RouterGroup
+	}
+	return group
+}
+
+func (group *RouterGroup) addRoute(method string, comp string, handler HandlerFunc) *RouteInfo {
+	group = group.resolve()
+	pattern := group.prefix + comp
+	log.Printf("GroupRoute %4s - %s", method, pattern)
+	return group.table.addRoute(group.host, method, pattern, handler)
+}
+
+// GET defines the method to add GET request
+func (group *RouterGroup) GET(pattern string, handler HandlerFunc) *RouteInfo {
+	return group.addRoute("GET", pattern, handler)
+}
+
+// POST defines the method to add POST request
+func (group *RouterGroup) POST(pattern string, handler HandlerFunc) *RouteInfo {
+	return group.addRoute("POST", pattern, handler)
+}
+
+// Handle registers a new request handler with the given method and pattern
+func (group *RouterGroup) Handle(method, pattern string, handler HandlerFunc) *RouteInfo {
+	return group.addRoute(method, pattern, handler)
+}
+
+// mountMethods 挂载 http.Handler 时注册的方法，包含 CONNECT 以支持 GeeRPC 等劫持连接的服务
+var mountMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace,
+}
+
+// Mount serves all requests under prefix with h, the prefix is stripped from the request path
+// before h is called. The group's middlewares are applied, and c.Writer is passed to h as is,
+// so handlers relying on http.Hijacker (eg. GeeRPC's CONNECT handler) keep working.
+func (group *RouterGroup) Mount(prefix string, h http.Handler) {
+	group = group.resolve()
+	prefix = strings.TrimSuffix(prefix, "/")
+	handler := WrapH(http.StripPrefix(group.prefix+prefix, h))
+	pattern := prefix
+	if pattern == "" {
+		pattern = "/"
+	}
+	for _, method := range mountMethods {
+		group.addRoute(method, pattern, handler).Mounted = true
+		group.addRoute(method, prefix+"/*filepath", handler).Mounted = true
+	}
+}
+
+func (group *RouterGroup) Use(middlewares ...HandlerFunc) {
+	group = group.resolve()
+	group.table.modify(func() {
+		group.middlewares = append(group.middlewares, middlewares...)
+	})
+}
+
+// WrapH wraps an http.Handler into a HandlerFunc, the request is passed to h unchanged
+func WrapH(h http.Handler) HandlerFunc {
+	return func(c *Context) {
+		h.ServeHTTP(c.Writer, c.Req)
+	}
+}
+
+// WrapF wraps an http.HandlerFunc into a HandlerFunc
+func WrapF(f http.HandlerFunc) HandlerFunc {
+	return WrapH(f)
+}
@@ -0,0 +1,118 @@
+package gee
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMount(t *testing.T) {
+	r := New()
+	api := r.Group("/api")
+	api.Use(func(c *Context) {
+		c.SetHeader("X-Group", "api")
+		c.Next()
+	})
+	api.Mount("/legacy", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = fmt.Fprintf(w, "%s %s", req.Method, req.URL.Path)
+	}))
+
+	for path, expect := range map[string]string{
+		"/api/legacy":       "PUT ",
+		"/api/legacy/a/b/c": "PUT /a/b/c",
+	} {
+		req := httptest.NewRequest(http.MethodPut, path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Body.String() != expect || w.Header().Get("X-Group") != "api" {
+			t.Fatalf("mount %s: expect %q with group middleware, got %q", path, expect, w.Body.String())
+		}
+	}
+}
+
+func TestMountConnect(t *testing.T) {
+	r := New()
+	r.Mount("/_rpc_", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error("hijack failed", err)
+			return
+		}
+		defer conn.Close()
+		_, _ = io.WriteString(conn, "HTTP/1.0 200 Connected\n\nhijacked")
+	}))
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, _ = io.WriteString(conn, "CONNECT /_rpc_ HTTP/1.0\n\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, &http.Request{Method: http.MethodConnect})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatal("failed to CONNECT through mounted handler", err)
+	}
+	if body, _ := ioutil.ReadAll(br); string(body) != "hijacked" {
+		t.Fatalf("expect hijacked connection, got %q", body)
+	}
+}
+
+func TestHost(t *testing.T) {
+	r := New()
+	r.Use(func(c *Context) {
+		c.SetHeader("X-Global", "1")
+		c.Next()
+	})
+	r.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "fallback")
+	})
+	r.Host("api.example.com").GET("/", func(c *Context) {
+		c.String(http.StatusOK, "api")
+	})
+	tenant := r.Host(":tenant.example.com").Group("/admin")
+	tenant.Use(func(c *Context) {
+		c.SetHeader("X-Group", "admin")
+		c.Next()
+	})
+	tenant.GET("/:page", func(c *Context) {
+		c.String(http.StatusOK, "%s %s", c.Param("tenant"), c.Param("page"))
+	})
+	r.Host("*sub.example.org").GET("/", func(c *Context) {
+		c.String(http.StatusOK, "wildcard %s", c.Param("sub"))
+	})
+	r.Host("static.example.net:8080").GET("/", func(c *Context) {
+		c.String(http.StatusOK, "static")
+	})
+
+	for _, tt := range []struct{ host, path, body, group string }{
+		{"api.example.com", "/", "api", ""},
+		{"acme.example.com:8080", "/admin/users", "acme users", "admin"},
+		{"a.b.example.org", "/", "wildcard a.b", ""},
+		{"API.example.com.:443", "/", "api", ""},
+		{"static.example.net", "/", "static", ""},
+		{"static.example.net:9090", "/", "static", ""},
+		{"[::1]", "/", "fallback", ""},
+		{"example.org", "/", "fallback", ""},
+		{"localhost", "/", "fallback", ""},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Host = tt.host
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Body.String() != tt.body || w.Header().Get("X-Global") != "1" || w.Header().Get("X-Group") != tt.group {
+			t.Fatalf("%s%s: unexpected response %q, header %v", tt.host, tt.path, w.Body.String(), w.Header())
+		}
+	}
+}
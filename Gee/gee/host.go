@@ -0,0 +1,119 @@
+package gee
+
+import (
+	"net"
+	"sort"
+	"strings"
+)
+
+// 基于 Host 的路由，每个 host 模式拥有独立的 router 和 group，例如
+// 1. 精确匹配: api.example.com
+// 2. 参数匹配: :tenant.example.com，可以匹配 acme.example.com，c.Param("tenant") == "acme"
+// 3. 通配匹配: *.example.com 或 *sub.example.com，只能出现在最左边，可以匹配一个或多个 label，
+// 后者将匹配到的部分保存为参数 sub
+// 没有匹配到任何 host 的请求交给路由表自身的 router 处理
+
+type host struct {
+	pattern string
+	labels  []string // labels 从右到左的各级域名，例如 [com example :tenant]
+	router  *router
+	groups  []*RouterGroup
+	static  int // static 非通配 label 数量，越多越优先
+	order   int // order 注册顺序，static 相同时先注册的优先
+}
+
+// Host returns the root group of the host pattern in the current table, see Table.Host
+func (e *Engine) Host(pattern string) *RouterGroup {
+	return e.table().Host(pattern)
+}
+
+// Host returns the root group of the host pattern, routes and groups created from it only match
+// requests to that host. Middlewares of the table's root group are applied to requests of all hosts.
+func (t *Table) Host(pattern string) *RouterGroup {
+	pattern = strings.ToLower(stripPort(pattern))
+	for _, h := range t.hosts {
+		if h.pattern == pattern {
+			return h.groups[0]
+		}
+	}
+	h := &host{pattern: pattern, router: newRouter(), order: len(t.hosts)}
+	labels := strings.Split(pattern, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		label := labels[i]
+		if label == "" || (label[0] == '*' && i != 0) {
+			panic("gee: invalid host pattern " + pattern)
+		}
+		if label[0] != ':' && label[0] != '*' {
+			h.static++
+		}
+		h.labels = append(h.labels, label)
+	}
+	h.groups = []*RouterGroup{{engine: t.engine, table: t, host: h}}
+	t.modify(func() {
+		t.hosts = append(t.hosts, h)
+		sort.SliceStable(t.hosts, func(i, j int) bool {
+			if t.hosts[i].static != t.hosts[j].static {
+				return t.hosts[i].static > t.hosts[j].static
+			}
+			return t.hosts[i].order < t.hosts[j].order
+		})
+	})
+	return h.groups[0]
+}
+
+// matchHost 返回匹配请求 host 的模式和 host 参数，没有匹配时返回 nil
+func (t *Table) matchHost(reqHost string) (*host, map[string]string) {
+	if len(t.hosts) == 0 {
+		return nil, nil
+	}
+	labels := strings.Split(strings.ToLower(strings.TrimSuffix(stripPort(reqHost), ".")), ".")
+	for _, h := range t.hosts {
+		if params, ok := h.match(labels); ok {
+			return h, params
+		}
+	}
+	return nil, nil
+}
+
+// stripPort 去掉 host 中的端口，例如 example.com:8080、[::1]:8080、[::1]，
+// 只有数字才是端口，:tenant.example.com 这样的参数 label 保持不变
+func stripPort(hostport string) string {
+	if name, port, err := net.SplitHostPort(hostport); err == nil && strings.Trim(port, "0123456789") == "" {
+		return name
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(hostport, "["), "]")
+}
+
+func (h *host) match(labels []string) (map[string]string, bool) {
+	var params map[string]string
+	for i, label := range h.labels {
+		if label[0] == '*' {
+			// 通配至少匹配一个 label
+			rest := len(labels) - i
+			if rest < 1 {
+				return nil, false
+			}
+			if len(label) > 1 {
+				if params == nil {
+					params = make(map[string]string)
+				}
+				params[label[1:]] = strings.Join(labels[:rest], ".")
+			}
+			return params, true
+		}
+		if i >= len(labels) {
+			return nil, false
+		}
+		value := labels[len(labels)-1-i]
+		switch {
+		case label[0] == ':' && len(label) > 1:
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[label[1:]] = value
+		case label != value:
+			return nil, false
+		}
+	}
+	return params, len(h.labels) == len(labels)
+}
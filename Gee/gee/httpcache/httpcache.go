@@ -0,0 +1,377 @@
+// Package httpcache 实现基于 GeeCache Group 的 HTTP 响应缓存中间件
+// 完整的响应(状态码、响应头、响应体)以 方法+路径+选定的查询参数/请求头 为 key 保存在 GeeCache Group 中，
+// Group 注册 HTTPPool 后缓存即可在集群内共享：
+// 1. key 归属本节点时，直接执行本次请求剩余的 handler 生成响应
+// 2. key 归属远程节点时，远程节点根据 key 还原请求，交给自己的 Engine 处理后返回响应
+// 缓存的响应通过 Group 的 TTL 过期。不可缓存的响应(非 200、Set-Cookie、Cache-Control: private 等)不会共享，
+// Group 中只保存一个不可缓存的标记，每个请求各自执行 handler
+package httpcache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MarkRepo/Gee/Gee/gee"
+	"github.com/MarkRepo/Gee/GeeCache/cache"
+)
+
+const (
+	defaultName       = "httpcache"
+	defaultCacheBytes = 64 << 20
+	defaultTTL        = time.Minute
+)
+
+// Options 响应缓存配置
+type Options struct {
+	Name        string        // Name GeeCache Group 名称，集群内各节点需要一致，默认 httpcache
+	CacheBytes  int64         // CacheBytes 缓存大小，默认 64MB
+	TTL         time.Duration // TTL 缓存时间，handler 通过 Cache-Control: max-age 可以进一步缩短，默认 1 分钟
+	VaryHeaders []string      // VaryHeaders 参与 key 计算的请求头
+	VaryQuery   []string      // VaryQuery 参与 key 计算的查询参数，为 nil 时使用全部查询参数
+}
+
+// Cache caches full responses of GET requests in a GeeCache Group
+type Cache struct {
+	opt    Options
+	engine *gee.Engine
+	group  *cache.Group
+
+	mu       sync.Mutex          // protect following
+	inflight map[string]*pending // inflight 正在等待缓存结果的本地请求，用于在本节点生成响应
+}
+
+// pending 等待缓存结果的本地请求
+type pending struct {
+	ctx   *gee.Context
+	entry *entry // entry load 执行了 ctx 的 handler 时，ctx 自己的响应
+}
+
+// entry 缓存的完整响应
+type entry struct {
+	Status      int
+	Header      http.Header
+	Body        []byte
+	Created     time.Time // Created 生成响应的时间，在请求开始之后生成的响应返回 X-Cache: MISS
+	Uncacheable bool      // Uncacheable 响应不可缓存，entry 中只有这个标记，请求各自执行 handler
+}
+
+// replayKey 标记由远程节点请求触发的重放请求，重放请求不再经过缓存
+type replayKey struct{}
+
+// New creates a Cache, engine is used to generate responses for keys requested by other peers
+func New(engine *gee.Engine, opt Options) *Cache {
+	if opt.Name == "" {
+		opt.Name = defaultName
+	}
+	if opt.CacheBytes == 0 {
+		opt.CacheBytes = defaultCacheBytes
+	}
+	if opt.TTL == 0 {
+		opt.TTL = defaultTTL
+	}
+	c := &Cache{
+		opt:      opt,
+		engine:   engine,
+		inflight: make(map[string]*pending),
+	}
+	c.group = cache.NewGroup(opt.Name, opt.CacheBytes, cache.GetterWithTTLFunc(c.load))
+	return c
+}
+
+// Group returns the underlying GeeCache Group, register peers on it to share the cache across the cluster
+func (c *Cache) Group() *cache.Group {
+	return c.group
+}
+
+// Middleware returns the middleware serving cached responses, it adds X-Cache: HIT/MISS to responses
+func (c *Cache) Middleware() gee.HandlerFunc {
+	return func(ctx *gee.Context) {
+		if ctx.Req.Context().Value(replayKey{}) != nil {
+			ctx.Next()
+			return
+		}
+		if ctx.Method != http.MethodGet || noCache(ctx.Req.Header) {
+			ctx.SetHeader("X-Cache", "MISS")
+			ctx.Next()
+			return
+		}
+
+		start := time.Now()
+		key := c.key(ctx.Req)
+		p := &pending{ctx: ctx}
+		c.mu.Lock()
+		if _, ok := c.inflight[key]; !ok {
+			c.inflight[key] = p
+		}
+		c.mu.Unlock()
+
+		e, err := c.get(key)
+
+		c.mu.Lock()
+		if c.inflight[key] == p {
+			delete(c.inflight, key)
+		}
+		own := p.entry
+		c.mu.Unlock()
+
+		switch {
+		case own != nil:
+			// handler 已经在 load 中执行过，返回它自己的响应
+			writeEntry(ctx, own, "MISS")
+		case err != nil:
+			ctx.String(http.StatusInternalServerError, "%s\n", err)
+			ctx.Abort()
+		case e.Uncacheable:
+			ctx.SetHeader("X-Cache", "MISS")
+			ctx.Next()
+		case e.Created.Before(start):
+			writeEntry(ctx, e, "HIT")
+		default:
+			// 本次请求期间生成的响应(远程节点重放或者并发请求共享的加载)不是缓存命中
+			writeEntry(ctx, e, "MISS")
+		}
+	}
+}
+
+// get 不使用请求的 ctx：load 可能正在 Group 的加载协程中执行本次请求剩余的 handler，
+// 请求取消后提前返回会让两个协程同时使用同一个 gee.Context，因此必须等待 load 结束
+func (c *Cache) get(key string) (*entry, error) {
+	view, err := c.group.Get(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+	return decodeEntry(view.ByteSlice())
+}
+
+// load 是 Group 的 getter，优先使用本节点正在等待的请求生成响应，否则根据 key 重放请求。
+// 等待的请求只会被使用一次，它的响应保存在 pending 中；
+// 不可缓存的响应只返回 Uncacheable 标记，不会交给共享这次加载的其他请求
+func (c *Cache) load(key string) ([]byte, time.Duration, error) {
+	c.mu.Lock()
+	p := c.inflight[key]
+	delete(c.inflight, key)
+	c.mu.Unlock()
+
+	var e *entry
+	var err error
+	if p != nil {
+		e = c.run(p.ctx)
+		c.mu.Lock()
+		p.entry = e
+		c.mu.Unlock()
+	} else if e, err = c.replay(key); err != nil {
+		return nil, 0, err
+	}
+	ttl, ok := cacheable(e, c.opt.TTL)
+	if !ok {
+		e = &entry{Uncacheable: true}
+		ttl = c.opt.TTL
+	}
+	shared := *e
+	shared.Created = time.Now()
+	b, err := encodeEntry(&shared)
+	return b, ttl, err
+}
+
+// run 执行 ctx 剩余的 handler，响应写入 recorder
+func (c *Cache) run(ctx *gee.Context) *entry {
+	w := newRecorder()
+	origin := ctx.Writer
+	ctx.Writer = w
+	ctx.Next()
+	ctx.Writer = origin
+	return w.entry()
+}
+
+// replay 根据 key 还原请求并交给 Engine 处理
+func (c *Cache) replay(key string) (*entry, error) {
+	lines := strings.Split(key, "\n")
+	first := strings.SplitN(lines[0], " ", 2)
+	if len(first) != 2 {
+		return nil, fmt.Errorf("httpcache: invalid key %q", key)
+	}
+	req, err := http.NewRequest(first[0], first[1], nil)
+	if err != nil {
+		return nil, err
+	}
+	req.RequestURI = first[1]
+	for _, line := range lines[1:] {
+		if kv := strings.SplitN(line, ": ", 2); len(kv) == 2 {
+			req.Header.Set(kv[0], kv[1])
+		}
+	}
+	w := newRecorder()
+	c.engine.ServeHTTP(w, req.WithContext(context.WithValue(context.Background(), replayKey{}, true)))
+	return w.entry(), nil
+}
+
+// key 格式：
+// GET /path?a=1&b=2
+// Header: value
+func (c *Cache) key(req *http.Request) string {
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteString(" ")
+	b.WriteString(req.URL.EscapedPath())
+
+	query := req.URL.Query()
+	if c.opt.VaryQuery != nil {
+		selected := url.Values{}
+		for _, name := range c.opt.VaryQuery {
+			if v, ok := query[name]; ok {
+				selected[name] = v
+			}
+		}
+		query = selected
+	}
+	if encoded := query.Encode(); encoded != "" {
+		b.WriteString("?")
+		b.WriteString(encoded)
+	}
+
+	headers := append([]string(nil), c.opt.VaryHeaders...)
+	sort.Strings(headers)
+	for _, name := range headers {
+		if v := req.Header.Get(name); v != "" {
+			b.WriteString("\n")
+			b.WriteString(http.CanonicalHeaderKey(name))
+			b.WriteString(": ")
+			b.WriteString(v)
+		}
+	}
+	return b.String()
+}
+
+// noCache 客户端要求不使用缓存
+func noCache(h http.Header) bool {
+	if h.Get("Pragma") == "no-cache" {
+		return true
+	}
+	directives := parseCacheControl(h.Get("Cache-Control"))
+	if _, ok := directives["no-cache"]; ok {
+		return true
+	}
+	if _, ok := directives["no-store"]; ok {
+		return true
+	}
+	return directives["max-age"] == "0"
+}
+
+// cacheable 仅缓存 200 响应，handler 通过 Cache-Control 或 Set-Cookie 可以拒绝缓存，
+// 返回缓存时间：ttl 和 Cache-Control: s-maxage/max-age 中较短的一个
+func cacheable(e *entry, ttl time.Duration) (time.Duration, bool) {
+	if e.Status != http.StatusOK || e.Header.Get("Set-Cookie") != "" {
+		return 0, false
+	}
+	directives := parseCacheControl(e.Header.Get("Cache-Control"))
+	for _, d := range []string{"no-store", "no-cache", "private"} {
+		if _, ok := directives[d]; ok {
+			return 0, false
+		}
+	}
+	maxAge := directives["s-maxage"]
+	if maxAge == "" {
+		maxAge = directives["max-age"]
+	}
+	if maxAge != "" {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil || seconds <= 0 {
+			return 0, false
+		}
+		if d := time.Duration(seconds) * time.Second; d < ttl {
+			ttl = d
+		}
+	}
+	return ttl, true
+}
+
+func parseCacheControl(v string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		name := strings.ToLower(kv[0])
+		if len(kv) == 2 {
+			directives[name] = strings.Trim(kv[1], `"`)
+		} else {
+			directives[name] = ""
+		}
+	}
+	return directives
+}
+
+func writeEntry(ctx *gee.Context, e *entry, status string) {
+	header := ctx.Writer.Header()
+	for k, v := range e.Header {
+		header[k] = v
+	}
+	header.Set("X-Cache", status)
+	ctx.Data(e.Status, e.Body)
+	ctx.Abort()
+}
+
+func encodeEntry(e *entry) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(e); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeEntry(b []byte) (*entry, error) {
+	var e entry
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&e); err != nil {
+		return nil, fmt.Errorf("httpcache: decoding entry: %v", err)
+	}
+	return &e, nil
+}
+
+// recorder 记录 handler 写出的响应
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newRecorder() *recorder {
+	return &recorder{header: make(http.Header)}
+}
+
+func (w *recorder) Header() http.Header {
+	return w.header
+}
+
+func (w *recorder) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *recorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+func (w *recorder) entry() *entry {
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	header := w.header.Clone()
+	header.Del("X-Cache")
+	return &entry{Status: status, Header: header, Body: w.body.Bytes()}
+}
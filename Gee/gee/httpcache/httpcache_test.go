@@ -0,0 +1,205 @@
+package httpcache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MarkRepo/Gee/Gee/gee"
+	"github.com/MarkRepo/Gee/GeeCache/cache"
+	pb "github.com/MarkRepo/Gee/GeeCache/cache/cachepb"
+)
+
+func newEngine(name string, calls *int) (*gee.Engine, *Cache) {
+	r := gee.New()
+	c := New(r, Options{Name: name, VaryQuery: []string{"page"}})
+	r.Use(c.Middleware())
+	r.GET("/items", func(ctx *gee.Context) {
+		*calls++
+		ctx.String(http.StatusOK, "page %s", ctx.Query("page"))
+	})
+	r.GET("/private", func(ctx *gee.Context) {
+		*calls++
+		ctx.SetHeader("Cache-Control", "no-store")
+		ctx.String(http.StatusOK, "private")
+	})
+	return r, c
+}
+
+func serve(r *gee.Engine, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCacheHit(t *testing.T) {
+	var calls int
+	r, _ := newEngine("httpcache-hit", &calls)
+
+	for i, expect := range []string{"MISS", "HIT"} {
+		w := serve(r, "/items?page=1&ignored="+string(rune('a'+i)), nil)
+		if w.Header().Get("X-Cache") != expect || w.Body.String() != "page 1" {
+			t.Fatalf("request %d: expect %s 'page 1', got %s %q", i, expect, w.Header().Get("X-Cache"), w.Body.String())
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expect handler called once, got %d", calls)
+	}
+
+	if w := serve(r, "/items?page=2", nil); w.Header().Get("X-Cache") != "MISS" || w.Body.String() != "page 2" {
+		t.Fatalf("different query should miss, got %s %q", w.Header().Get("X-Cache"), w.Body.String())
+	}
+	if w := serve(r, "/items?page=1", http.Header{"Cache-Control": {"no-cache"}}); w.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("client no-cache should bypass the cache")
+	}
+	if calls != 3 {
+		t.Fatalf("expect handler called 3 times, got %d", calls)
+	}
+}
+
+// remotePeer 将所有 key 交给另一个节点的 Group 加载
+type remotePeer struct {
+	group *cache.Group
+}
+
+func (p *remotePeer) PickPeer(key string) (cache.PeerGetter, bool) {
+	return p, true
+}
+
+func (p *remotePeer) Get(ctx context.Context, in *pb.Request, out *pb.Response) error {
+	view, err := p.group.Get(ctx, in.Key)
+	out.Value = view.ByteSlice()
+	return err
+}
+
+func TestCacheRemoteHit(t *testing.T) {
+	var localCalls, remoteCalls int
+	local, c := newEngine("httpcache-remote-local", &localCalls)
+	_, owner := newEngine("httpcache-remote-owner", &remoteCalls)
+	c.Group().RegisterPeers(&remotePeer{group: owner.Group()})
+
+	for i, expect := range []string{"MISS", "HIT"} {
+		w := serve(local, "/items?page=4", nil)
+		if w.Header().Get("X-Cache") != expect || w.Body.String() != "page 4" {
+			t.Fatalf("request %d: expect %s 'page 4', got %s %q", i, expect, w.Header().Get("X-Cache"), w.Body.String())
+		}
+	}
+	if localCalls != 0 || remoteCalls != 1 {
+		t.Fatalf("expect the owner to generate the response once, got local %d remote %d", localCalls, remoteCalls)
+	}
+}
+
+func TestCacheUncacheableNotShared(t *testing.T) {
+	var calls int32
+	started, release := make(chan struct{}, 2), make(chan struct{})
+	r := gee.New()
+	c := New(r, Options{Name: "httpcache-uncacheable", VaryQuery: []string{}})
+	r.Use(c.Middleware())
+	r.GET("/session", func(ctx *gee.Context) {
+		atomic.AddInt32(&calls, 1)
+		started <- struct{}{}
+		<-release
+		ctx.SetHeader("Set-Cookie", "user="+ctx.Query("user"))
+		ctx.String(http.StatusOK, "hello %s", ctx.Query("user"))
+	})
+
+	var wg sync.WaitGroup
+	for _, user := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(user string) {
+			defer wg.Done()
+			w := serve(r, "/session?user="+user, nil)
+			if w.Header().Get("Set-Cookie") != "user="+user || w.Body.String() != "hello "+user || w.Header().Get("X-Cache") != "MISS" {
+				t.Errorf("%s got response of another request: %v %q", user, w.Header(), w.Body.String())
+			}
+		}(user)
+		if user == "a" {
+			<-started
+		}
+	}
+	// 等待 b 加入 a 的加载
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls != 2 {
+		t.Fatalf("each request should run its own handler, got %d calls", calls)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	var calls int
+	r := gee.New()
+	c := New(r, Options{Name: "httpcache-ttl", TTL: 50 * time.Millisecond})
+	r.Use(c.Middleware())
+	r.GET("/", func(ctx *gee.Context) {
+		calls++
+		ctx.String(http.StatusOK, "ok")
+	})
+	for i, expect := range []string{"MISS", "HIT", "MISS"} {
+		if i == 2 {
+			time.Sleep(60 * time.Millisecond)
+		}
+		if w := serve(r, "/", nil); w.Header().Get("X-Cache") != expect {
+			t.Fatalf("request %d: expect %s, got %s", i, expect, w.Header().Get("X-Cache"))
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("expired response should be generated again, handler called %d times", calls)
+	}
+}
+
+func TestCacheCanceledRequest(t *testing.T) {
+	var calls int
+	r, _ := newEngine("httpcache-canceled", &calls)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/items?page=5", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "page 5" || calls != 1 {
+		t.Fatalf("canceled request should wait for the handler, got %d %q, calls %d", w.Code, w.Body.String(), calls)
+	}
+}
+
+func TestCacheNotCacheable(t *testing.T) {
+	var calls int
+	r, _ := newEngine("httpcache-private", &calls)
+	for i := 0; i < 2; i++ {
+		w := serve(r, "/private", nil)
+		if w.Header().Get("X-Cache") != "MISS" || w.Body.String() != "private" {
+			t.Fatalf("expect MISS 'private', got %s %q", w.Header().Get("X-Cache"), w.Body.String())
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("no-store response should not be cached, handler called %d times", calls)
+	}
+	if w := serve(r, "/missing", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("expect 404, got %d", w.Code)
+	}
+}
+
+func TestCacheReplay(t *testing.T) {
+	var calls int
+	_, c := newEngine("httpcache-replay", &calls)
+	// 模拟远程节点请求：本节点没有等待中的请求，根据 key 重放
+	req := httptest.NewRequest(http.MethodGet, "/items?page=3", nil)
+	view, err := c.Group().Get(context.Background(), c.key(req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := decodeEntry(view.ByteSlice())
+	if err != nil || e.Status != http.StatusOK || string(e.Body) != "page 3" {
+		t.Fatalf("unexpected replayed entry %+v, err: %v", e, err)
+	}
+	if calls != 1 {
+		t.Fatalf("expect handler called once, got %d", calls)
+	}
+}
@@ -0,0 +1,222 @@
+// Package jwt 实现 JSON Web Token 的签发和校验，只依赖标准库
+// 1. 签名算法：HS256、RS256、ES256
+// 2. 校验 exp、nbf、aud、iss，允许一定的时钟偏差
+// 3. 通过 Keyset 按 kid 查找验签密钥，支持密钥轮换
+// 4. 中间件校验 Bearer token 并把 Claims 保存到 gee.Context，RequireRoles 按角色保护路由组
+package jwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoToken     = errors.New("jwt: token not found")
+	ErrMalformed   = errors.New("jwt: malformed token")
+	ErrAlgorithm   = errors.New("jwt: unsupported algorithm")
+	ErrUnknownKey  = errors.New("jwt: unknown key")
+	ErrSignature   = errors.New("jwt: invalid signature")
+	ErrExpired     = errors.New("jwt: token is expired")
+	ErrNotValidYet = errors.New("jwt: token is not valid yet")
+	ErrAudience    = errors.New("jwt: invalid audience")
+	ErrIssuer      = errors.New("jwt: invalid issuer")
+	ErrRole        = errors.New("jwt: missing required role")
+)
+
+// Audience is the aud claim, it is encoded as a string if there is only one audience
+type Audience []string
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = Audience{s}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(a))
+}
+
+func (a Audience) contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+	return false
+}
+
+// Claims are the registered claims and the roles used by RequireRoles,
+// other claims are kept in Custom
+type Claims struct {
+	Issuer    string   `json:"iss,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"` // ExpiresAt unix 秒
+	NotBefore int64    `json:"nbf,omitempty"` // NotBefore unix 秒
+	IssuedAt  int64    `json:"iat,omitempty"` // IssuedAt unix 秒
+	ID        string   `json:"jti,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+
+	Custom map[string]interface{} `json:"-"` // Custom 其他自定义 claim
+}
+
+type registeredClaims Claims
+
+func (c *Claims) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal((*registeredClaims)(c))
+	if err != nil || len(c.Custom) == 0 {
+		return b, err
+	}
+	m := make(map[string]interface{}, len(c.Custom))
+	for k, v := range c.Custom {
+		m[k] = v
+	}
+	// 已注册的 claim 优先
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
+func (c *Claims) UnmarshalJSON(b []byte) error {
+	// NumericDate 可以是小数，例如 1516239022.5，解析后舍去小数部分
+	aux := struct {
+		*registeredClaims
+		ExpiresAt float64 `json:"exp,omitempty"`
+		NotBefore float64 `json:"nbf,omitempty"`
+		IssuedAt  float64 `json:"iat,omitempty"`
+	}{registeredClaims: (*registeredClaims)(c)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	c.ExpiresAt, c.NotBefore, c.IssuedAt = int64(aux.ExpiresAt), int64(aux.NotBefore), int64(aux.IssuedAt)
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	for _, k := range []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti", "roles"} {
+		delete(m, k)
+	}
+	if len(m) > 0 {
+		c.Custom = m
+	}
+	return nil
+}
+
+// HasRole reports whether the claims contain role
+func (c *Claims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+var encoding = base64.RawURLEncoding
+
+// Sign creates a signed token of claims
+func Sign(claims *Claims, key *Key) (string, error) {
+	h, err := json.Marshal(header{Alg: key.Algorithm, Typ: "JWT", Kid: key.ID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signing := encoding.EncodeToString(h) + "." + encoding.EncodeToString(payload)
+	sig, err := key.sign([]byte(signing))
+	if err != nil {
+		return "", err
+	}
+	return signing + "." + encoding.EncodeToString(sig), nil
+}
+
+// Verifier verifies tokens and their claims
+type Verifier struct {
+	Keys     *Keyset
+	Issuer   string        // Issuer 不为空时 iss 必须一致
+	Audience string        // Audience 不为空时 aud 必须包含该值
+	Skew     time.Duration // Skew 校验 exp、nbf 时允许的时钟偏差
+
+	now func() time.Time // now 用于测试
+}
+
+// Verify checks the signature and the claims of token
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	var h header
+	if err := decodeJSON(parts[0], &h); err != nil {
+		return nil, ErrMalformed
+	}
+	if h.Alg != HS256 && h.Alg != RS256 && h.Alg != ES256 {
+		return nil, ErrAlgorithm
+	}
+	key, ok := v.Keys.Get(h.Kid)
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if key.Algorithm != h.Alg {
+		return nil, ErrAlgorithm
+	}
+	sig, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if !key.verify([]byte(parts[0]+"."+parts[1]), sig) {
+		return nil, ErrSignature
+	}
+
+	var claims Claims
+	if err := decodeJSON(parts[1], &claims); err != nil {
+		return nil, ErrMalformed
+	}
+	return &claims, v.validate(&claims)
+}
+
+func (v *Verifier) validate(c *Claims) error {
+	now := time.Now()
+	if v.now != nil {
+		now = v.now()
+	}
+	if c.ExpiresAt != 0 && !now.Add(-v.Skew).Before(time.Unix(c.ExpiresAt, 0)) {
+		return ErrExpired
+	}
+	if c.NotBefore != 0 && now.Add(v.Skew).Before(time.Unix(c.NotBefore, 0)) {
+		return ErrNotValidYet
+	}
+	if v.Issuer != "" && c.Issuer != v.Issuer {
+		return ErrIssuer
+	}
+	if v.Audience != "" && !c.Audience.contains(v.Audience) {
+		return ErrAudience
+	}
+	return nil
+}
+
+func decodeJSON(s string, v interface{}) error {
+	b, err := encoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
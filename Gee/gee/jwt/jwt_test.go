@@ -0,0 +1,137 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MarkRepo/Gee/Gee/gee"
+)
+
+func TestSignVerify(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	keys := []*Key{
+		HS256Key("hs", []byte("secret")),
+		RS256Key("rs", rsaKey),
+		ES256Key("es", ecKey),
+	}
+	v := &Verifier{
+		Keys:     NewKeyset(HS256Key("hs", []byte("secret")), RS256PublicKey("rs", &rsaKey.PublicKey), ES256PublicKey("es", &ecKey.PublicKey)),
+		Issuer:   "gee",
+		Audience: "api",
+	}
+	for _, key := range keys {
+		claims := &Claims{Issuer: "gee", Audience: Audience{"api"}, Subject: "tom", Custom: map[string]interface{}{"tenant": "acme"}}
+		token, err := Sign(claims, key)
+		if err != nil {
+			t.Fatalf("%s: failed to sign: %v", key.Algorithm, err)
+		}
+		got, err := v.Verify(token)
+		if err != nil || got.Subject != "tom" || got.Custom["tenant"] != "acme" {
+			t.Fatalf("%s: failed to verify: %+v, %v", key.Algorithm, got, err)
+		}
+		if _, err := v.Verify(token[:len(token)-2] + "AA"); err != ErrSignature {
+			t.Fatalf("%s: expect ErrSignature, got %v", key.Algorithm, err)
+		}
+	}
+
+	// 使用 RS256 公钥作为 HS256 密钥伪造签名
+	forged, _ := Sign(&Claims{}, HS256Key("rs", []byte("forged")))
+	if _, err := v.Verify(forged); err != ErrAlgorithm {
+		t.Fatalf("expect ErrAlgorithm, got %v", err)
+	}
+	v.Keys.Remove("hs")
+	token, _ := Sign(&Claims{Issuer: "gee", Audience: Audience{"api"}}, keys[0])
+	if _, err := v.Verify(token); err != ErrUnknownKey {
+		t.Fatalf("expect ErrUnknownKey after rotation, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1000, 0)
+	v := &Verifier{Issuer: "gee", Audience: "api", Skew: 5 * time.Second, now: func() time.Time { return now }}
+	for _, tt := range []struct {
+		claims Claims
+		err    error
+	}{
+		{Claims{Issuer: "gee", Audience: Audience{"web", "api"}, ExpiresAt: 1003}, nil},
+		{Claims{Issuer: "gee", Audience: Audience{"api"}, ExpiresAt: 995}, ErrExpired},
+		{Claims{Issuer: "gee", Audience: Audience{"api"}, NotBefore: 1004}, nil},
+		{Claims{Issuer: "gee", Audience: Audience{"api"}, NotBefore: 1006}, ErrNotValidYet},
+		{Claims{Issuer: "other", Audience: Audience{"api"}}, ErrIssuer},
+		{Claims{Issuer: "gee", Audience: Audience{"web"}}, ErrAudience},
+	} {
+		if err := v.validate(&tt.claims); err != tt.err {
+			t.Fatalf("%+v: expect %v, got %v", tt.claims, tt.err, err)
+		}
+	}
+
+	// NumericDate 可以是小数
+	var c Claims
+	if err := json.Unmarshal([]byte(`{"exp":1003.5,"nbf":999.9,"iat":999.9,"role":"x"}`), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.ExpiresAt != 1003 || c.NotBefore != 999 || c.IssuedAt != 999 || c.Custom["role"] != "x" {
+		t.Fatalf("unexpected claims %+v", c)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	key := HS256Key("", []byte("secret"))
+	r := gee.New()
+	var handled error
+	r.Use(New(Options{
+		Verifier: &Verifier{Keys: NewKeyset(key)},
+		ErrorHandler: func(c *gee.Context, code int, err error) {
+			handled = err
+			c.String(code, "%v", err)
+		},
+	}))
+	r.GET("/me", func(c *gee.Context) {
+		c.String(http.StatusOK, "%s", FromContext(c).Subject)
+	})
+	admin := r.Group("/admin")
+	admin.Use(RequireRoles("admin"))
+	admin.GET("/stats", func(c *gee.Context) {
+		c.String(http.StatusOK, "stats")
+	})
+
+	serve := func(path string, claims *Claims) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if claims != nil {
+			token, _ := Sign(claims, key)
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := serve("/me", nil); w.Code != http.StatusUnauthorized || handled != ErrNoToken {
+		t.Fatalf("expect 401, got %d", w.Code)
+	}
+	if w := serve("/me", &Claims{Subject: "tom"}); w.Code != http.StatusOK || w.Body.String() != "tom" {
+		t.Fatalf("expect 200 tom, got %d %q", w.Code, w.Body.String())
+	}
+	if w := serve("/admin/stats", &Claims{Subject: "tom"}); w.Code != http.StatusForbidden || handled != ErrRole {
+		t.Fatalf("expect 403 reported by ErrorHandler, got %d %v", w.Code, handled)
+	}
+	if w := serve("/admin/stats", &Claims{Subject: "tom", Roles: []string{"admin"}}); w.Code != http.StatusOK {
+		t.Fatalf("expect 200, got %d", w.Code)
+	}
+
+	// 没有中间件时 RequireRoles 使用默认的 ErrorHandler
+	plain := gee.New()
+	plain.GET("/", RequireRoles("admin"))
+	w := httptest.NewRecorder()
+	plain.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("expect default 401, got %d", w.Code)
+	}
+}
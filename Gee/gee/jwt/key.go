@@ -0,0 +1,152 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"sync"
+)
+
+// 支持的签名算法
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+)
+
+// Key 签名或验签使用的密钥，Algorithm 固定，验签时 token 头部的 alg 必须与之一致，防止算法混淆攻击
+type Key struct {
+	ID        string // ID 对应 token 头部的 kid
+	Algorithm string
+
+	secret  []byte           // secret HS256 密钥
+	private crypto.Signer    // private RS256/ES256 私钥，只用于验签时为 nil
+	public  crypto.PublicKey // public RS256/ES256 公钥
+}
+
+// HS256Key creates a key signing and verifying with HMAC SHA-256
+func HS256Key(kid string, secret []byte) *Key {
+	return &Key{ID: kid, Algorithm: HS256, secret: secret}
+}
+
+// RS256Key creates a key signing with the RSA private key and verifying with its public key
+func RS256Key(kid string, private *rsa.PrivateKey) *Key {
+	return &Key{ID: kid, Algorithm: RS256, private: private, public: &private.PublicKey}
+}
+
+// RS256PublicKey creates a key only verifying RS256 signatures
+func RS256PublicKey(kid string, public *rsa.PublicKey) *Key {
+	return &Key{ID: kid, Algorithm: RS256, public: public}
+}
+
+// ES256Key creates a key signing with the P-256 private key and verifying with its public key
+func ES256Key(kid string, private *ecdsa.PrivateKey) *Key {
+	return &Key{ID: kid, Algorithm: ES256, private: private, public: &private.PublicKey}
+}
+
+// ES256PublicKey creates a key only verifying ES256 signatures
+func ES256PublicKey(kid string, public *ecdsa.PublicKey) *Key {
+	return &Key{ID: kid, Algorithm: ES256, public: public}
+}
+
+var errNoPrivateKey = errors.New("jwt: key can not sign")
+
+func (k *Key) sign(data []byte) ([]byte, error) {
+	sum := sha256.Sum256(data)
+	switch k.Algorithm {
+	case HS256:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(data)
+		return mac.Sum(nil), nil
+	case RS256:
+		if k.private == nil {
+			return nil, errNoPrivateKey
+		}
+		return k.private.Sign(rand.Reader, sum[:], crypto.SHA256)
+	case ES256:
+		priv, ok := k.private.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, errNoPrivateKey
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, priv, sum[:])
+		if err != nil {
+			return nil, err
+		}
+		// JWS 使用定长的 r || s，而不是 ASN.1 编码
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig, nil
+	}
+	return nil, ErrAlgorithm
+}
+
+func (k *Key) verify(data, sig []byte) bool {
+	sum := sha256.Sum256(data)
+	switch k.Algorithm {
+	case HS256:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(data)
+		return hmac.Equal(sig, mac.Sum(nil))
+	case RS256:
+		pub, ok := k.public.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(pub, crypto.SHA256, sum[:], sig) == nil
+	case ES256:
+		pub, ok := k.public.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != elliptic.P256() || len(sig) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(pub, sum[:], r, s)
+	}
+	return false
+}
+
+// Keyset holds the verification keys by kid, keys can be added and removed at runtime to rotate them
+type Keyset struct {
+	mu   sync.RWMutex
+	keys map[string]*Key
+}
+
+// NewKeyset creates a Keyset with keys
+func NewKeyset(keys ...*Key) *Keyset {
+	ks := &Keyset{keys: make(map[string]*Key)}
+	ks.Add(keys...)
+	return ks
+}
+
+// Add adds or replaces keys with the same kid
+func (ks *Keyset) Add(keys ...*Key) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	for _, k := range keys {
+		ks.keys[k.ID] = k
+	}
+}
+
+// Remove removes the key of kid, tokens signed by it are rejected afterwards
+func (ks *Keyset) Remove(kid string) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	delete(ks.keys, kid)
+}
+
+// Get returns the key of kid. A token without kid can only be verified if there is exactly one key.
+func (ks *Keyset) Get(kid string) (*Key, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if kid == "" && len(ks.keys) == 1 {
+		for _, k := range ks.keys {
+			return k, true
+		}
+	}
+	k, ok := ks.keys[kid]
+	return k, ok
+}
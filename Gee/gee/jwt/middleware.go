@@ -0,0 +1,102 @@
+package jwt
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/MarkRepo/Gee/Gee/gee"
+)
+
+const (
+	claimsKey       = "jwt.claims"        // claimsKey 保存在 gee.Context 中的 Claims
+	errorHandlerKey = "jwt.error_handler" // errorHandlerKey 中间件的 ErrorHandler，RequireRoles 也使用它
+)
+
+// Options 中间件配置
+type Options struct {
+	Verifier     *Verifier                      // Verifier 必填
+	TokenLookup  func(*gee.Context) string      // TokenLookup 获取 token，默认读取 Authorization: Bearer <token>
+	Optional     bool                           // Optional 为 true 时没有 token 的请求也放行，只是不设置 Claims
+	ErrorHandler func(*gee.Context, int, error) // ErrorHandler 认证失败时调用，默认返回 401/403
+}
+
+// New returns the middleware verifying the bearer token and storing the claims on the context
+func New(opt Options) gee.HandlerFunc {
+	if opt.Verifier == nil {
+		panic("jwt: Verifier is required")
+	}
+	if opt.TokenLookup == nil {
+		opt.TokenLookup = bearerToken
+	}
+	if opt.ErrorHandler == nil {
+		opt.ErrorHandler = defaultErrorHandler
+	}
+	return func(c *gee.Context) {
+		c.Set(errorHandlerKey, opt.ErrorHandler)
+		token := opt.TokenLookup(c)
+		if token == "" {
+			if opt.Optional {
+				c.Next()
+				return
+			}
+			opt.ErrorHandler(c, http.StatusUnauthorized, ErrNoToken)
+			c.Abort()
+			return
+		}
+		claims, err := opt.Verifier.Verify(token)
+		if err != nil {
+			opt.ErrorHandler(c, http.StatusUnauthorized, err)
+			c.Abort()
+			return
+		}
+		c.Set(claimsKey, claims)
+		c.Next()
+	}
+}
+
+// FromContext returns the claims stored by the middleware, or nil if the request is not authenticated
+func FromContext(c *gee.Context) *Claims {
+	v, _ := c.Get(claimsKey)
+	claims, _ := v.(*Claims)
+	return claims
+}
+
+// RequireRoles returns a guard allowing only requests whose claims contain all roles, use it after
+// the middleware, eg. admin.Use(jwt.RequireRoles("admin")). Failures are reported by the ErrorHandler of the middleware
+func RequireRoles(roles ...string) gee.HandlerFunc {
+	return func(c *gee.Context) {
+		errorHandler := defaultErrorHandler
+		if v, ok := c.Get(errorHandlerKey); ok {
+			errorHandler = v.(func(*gee.Context, int, error))
+		}
+		claims := FromContext(c)
+		if claims == nil {
+			errorHandler(c, http.StatusUnauthorized, ErrNoToken)
+			c.Abort()
+			return
+		}
+		for _, role := range roles {
+			if !claims.HasRole(role) {
+				errorHandler(c, http.StatusForbidden, ErrRole)
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+func bearerToken(c *gee.Context) string {
+	auth := c.Req.Header.Get("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+func defaultErrorHandler(c *gee.Context, code int, err error) {
+	if code == http.StatusUnauthorized {
+		c.SetHeader("WWW-Authenticate", `Bearer error="invalid_token"`)
+	}
+	c.String(code, "%s\n", http.StatusText(code))
+}
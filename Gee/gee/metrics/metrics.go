@@ -0,0 +1,276 @@
+// Package metrics 实现 Prometheus 文本格式的请求指标采集中间件，不依赖任何第三方库
+// 所有指标按匹配到的路由(c.Pattern)而不是原始路径打标签，避免 /user/1、/user/2 ... 造成标签基数爆炸
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MarkRepo/Gee/Gee/gee"
+)
+
+// unmatchedRoute 未匹配到路由的请求使用的 route 标签
+const unmatchedRoute = "unmatched"
+
+// otherMethod 非标准 HTTP 方法使用的 method 标签，避免客户端任意构造方法导致标签无限增长
+const otherMethod = "OTHER"
+
+// methodLabel 将请求方法映射为 method 标签
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return otherMethod
+}
+
+// DefaultBuckets are the default latency histogram buckets in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Options 指标采集配置
+type Options struct {
+	Namespace string    // Namespace 指标名前缀，默认 gee
+	Path      string    // Path 暴露指标的路由，默认 /metrics
+	Buckets   []float64 // Buckets 请求耗时直方图的桶，默认 DefaultBuckets
+}
+
+// Metrics collects request metrics of a gee Engine
+type Metrics struct {
+	namespace string
+	path      string
+	buckets   []float64
+
+	mu       sync.Mutex // protect following
+	requests map[requestKey]uint64
+	routes   map[routeKey]*routeStats
+}
+
+type routeKey struct {
+	method string
+	route  string
+}
+
+type requestKey struct {
+	routeKey
+	code int
+}
+
+// routeStats 单个路由的在途请求数、耗时直方图和响应大小汇总
+type routeStats struct {
+	inFlight int64
+	duration histogram
+	size     summary
+}
+
+// histogram 累积直方图，counts[i] 为落在 (buckets[i-1], buckets[i]] 区间的次数
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+type summary struct {
+	count uint64
+	sum   float64
+}
+
+// New creates a Metrics instance
+func New(opts ...Options) *Metrics {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Namespace == "" {
+		opt.Namespace = "gee"
+	}
+	if opt.Path == "" {
+		opt.Path = "/metrics"
+	}
+	if len(opt.Buckets) == 0 {
+		opt.Buckets = DefaultBuckets
+	}
+	buckets := append([]float64(nil), opt.Buckets...)
+	sort.Float64s(buckets)
+	return &Metrics{
+		namespace: opt.Namespace,
+		path:      opt.Path,
+		buckets:   buckets,
+		requests:  make(map[requestKey]uint64),
+		routes:    make(map[routeKey]*routeStats),
+	}
+}
+
+// Middleware returns the middleware collecting metrics of every request
+func (m *Metrics) Middleware() gee.HandlerFunc {
+	return func(c *gee.Context) {
+		key := routeKey{method: methodLabel(c.Method), route: c.Pattern}
+		if key.route == "" {
+			key.route = unmatchedRoute
+		}
+		m.mu.Lock()
+		stats := m.routes[key]
+		if stats == nil {
+			stats = &routeStats{duration: histogram{counts: make([]uint64, len(m.buckets))}}
+			m.routes[key] = stats
+		}
+		stats.inFlight++
+		m.mu.Unlock()
+
+		w := &responseWriter{ResponseWrapper: gee.ResponseWrapper{ResponseWriter: c.Writer}}
+		c.Writer = w
+		start := time.Now()
+		defer func() {
+			c.Writer = w.ResponseWriter
+			m.observe(key, stats, w.status(), time.Since(start), w.size)
+		}()
+		c.Next()
+	}
+}
+
+func (m *Metrics) observe(key routeKey, stats *routeStats, code int, d time.Duration, size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats.inFlight--
+	m.requests[requestKey{routeKey: key, code: code}]++
+
+	seconds := d.Seconds()
+	h := &stats.duration
+	h.count++
+	h.sum += seconds
+	if i := sort.SearchFloat64s(m.buckets, seconds); i < len(m.buckets) {
+		h.counts[i]++
+	}
+	stats.size.count++
+	stats.size.sum += float64(size)
+}
+
+// Register exposes the metrics in the Prometheus text format on the configured path of group
+func (m *Metrics) Register(group *gee.RouterGroup) {
+	group.GET(m.path, m.Handler())
+}
+
+// Handler returns the handler writing the metrics in the Prometheus text format
+func (m *Metrics) Handler() gee.HandlerFunc {
+	return func(c *gee.Context) {
+		c.SetHeader("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.Status(http.StatusOK)
+		bw := bufio.NewWriter(c.Writer)
+		m.write(bw)
+		_ = bw.Flush()
+	}
+}
+
+// write 按 Prometheus 文本格式输出所有指标，序列按标签排序保证输出稳定
+func (m *Metrics) write(w *bufio.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name := m.namespace + "_http_requests_total"
+	fmt.Fprintf(w, "# HELP %s Total number of HTTP requests by route pattern and status code.\n", name)
+	fmt.Fprintf(w, "# TYPE %s counter\n", name)
+	reqKeys := make([]requestKey, 0, len(m.requests))
+	for k := range m.requests {
+		reqKeys = append(reqKeys, k)
+	}
+	sort.Slice(reqKeys, func(i, j int) bool {
+		if reqKeys[i].routeKey != reqKeys[j].routeKey {
+			return lessRoute(reqKeys[i].routeKey, reqKeys[j].routeKey)
+		}
+		return reqKeys[i].code < reqKeys[j].code
+	})
+	for _, k := range reqKeys {
+		fmt.Fprintf(w, "%s{%s,code=\"%d\"} %d\n", name, k.labels(), k.code, m.requests[k])
+	}
+
+	routes := make([]routeKey, 0, len(m.routes))
+	for k := range m.routes {
+		routes = append(routes, k)
+	}
+	sort.Slice(routes, func(i, j int) bool { return lessRoute(routes[i], routes[j]) })
+
+	name = m.namespace + "_http_request_duration_seconds"
+	fmt.Fprintf(w, "# HELP %s HTTP request latency by route pattern.\n", name)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+	for _, k := range routes {
+		h := &m.routes[k].duration
+		var cumulative uint64
+		for i, upper := range m.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, k.labels(), formatFloat(upper), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, k.labels(), h.count)
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", name, k.labels(), formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", name, k.labels(), h.count)
+	}
+
+	name = m.namespace + "_http_requests_in_flight"
+	fmt.Fprintf(w, "# HELP %s Number of HTTP requests currently being served by route pattern.\n", name)
+	fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+	for _, k := range routes {
+		fmt.Fprintf(w, "%s{%s} %d\n", name, k.labels(), m.routes[k].inFlight)
+	}
+
+	name = m.namespace + "_http_response_size_bytes"
+	fmt.Fprintf(w, "# HELP %s HTTP response body size by route pattern.\n", name)
+	fmt.Fprintf(w, "# TYPE %s summary\n", name)
+	for _, k := range routes {
+		s := &m.routes[k].size
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", name, k.labels(), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", name, k.labels(), s.count)
+	}
+}
+
+func (k routeKey) labels() string {
+	return fmt.Sprintf("method=\"%s\",route=\"%s\"", escape(k.method), escape(k.route))
+}
+
+func lessRoute(a, b routeKey) bool {
+	if a.route != b.route {
+		return a.route < b.route
+	}
+	return a.method < b.method
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// responseWriter 记录响应状态码和响应体大小，同时保留 Flusher 和 Hijacker 能力
+type responseWriter struct {
+	gee.ResponseWrapper
+	code int
+	size int
+}
+
+func (w *responseWriter) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
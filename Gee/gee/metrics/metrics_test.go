@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MarkRepo/Gee/Gee/gee"
+)
+
+func TestMetrics(t *testing.T) {
+	r := gee.New()
+	m := New(Options{Buckets: []float64{1, 0.1}})
+	r.Use(m.Middleware())
+	m.Register(r.RouterGroup)
+	r.GET("/hello/:name", func(c *gee.Context) {
+		c.String(http.StatusOK, "hello %s", c.Param("name"))
+	})
+
+	for _, path := range []string{"/hello/tom", "/hello/jack", "/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	for _, method := range []string{"FOO", "BAR"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/missing", nil))
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+
+	for _, expect := range []string{
+		`gee_http_requests_total{method="GET",route="/hello/:name",code="200"} 2`,
+		`gee_http_requests_total{method="GET",route="unmatched",code="404"} 1`,
+		`gee_http_requests_total{method="OTHER",route="unmatched",code="404"} 2`,
+		`gee_http_request_duration_seconds_bucket{method="GET",route="/hello/:name",le="0.1"} 2`,
+		`gee_http_request_duration_seconds_bucket{method="GET",route="/hello/:name",le="+Inf"} 2`,
+		`gee_http_requests_in_flight{method="GET",route="/metrics"} 1`,
+		`gee_http_response_size_bytes_sum{method="GET",route="/hello/:name"} 19`,
+		`gee_http_response_size_bytes_count{method="GET",route="/hello/:name"} 2`,
+	} {
+		if !strings.Contains(body, expect+"\n") {
+			t.Fatalf("expect %q in metrics output:\n%s", expect, body)
+		}
+	}
+	if strings.Contains(body, "/hello/tom") || strings.Contains(body, "FOO") {
+		t.Fatal("raw path or method should not be used as label")
+	}
+}
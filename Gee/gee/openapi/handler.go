@@ -0,0 +1,147 @@
+package openapi
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/MarkRepo/Gee/Gee/gee"
+)
+
+// DefaultPrefix is the default path prefix of the document routes
+const DefaultPrefix = "/docs"
+
+// Register serves the generated document at <prefix>/openapi.json and the viewer at <prefix>.
+// The document is generated on every request, so routes registered after Register are included.
+func Register(e *gee.Engine, info Info, prefix ...string) {
+	register(e, e.RouterGroup, "", info, prefix)
+}
+
+// RegisterHost is like Register, but serves the document of the host pattern on that host, see GenerateHost
+func RegisterHost(e *gee.Engine, host string, info Info, prefix ...string) {
+	register(e, e.Host(host), host, info, prefix)
+}
+
+func register(e *gee.Engine, group *gee.RouterGroup, host string, info Info, prefix []string) {
+	p := DefaultPrefix
+	if len(prefix) > 0 {
+		p = prefix[0]
+	}
+	specURL := p + "/openapi.json"
+	group.GET(specURL, func(c *gee.Context) {
+		c.JSON(http.StatusOK, GenerateHost(e, host, info))
+	})
+	group.GET(p, func(c *gee.Context) {
+		c.SetHeader("Content-Type", "text/html; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := viewer.Execute(c.Writer, map[string]string{"Title": info.Title, "SpecURL": specURL}); err != nil {
+			_, _ = c.Writer.Write([]byte(err.Error()))
+		}
+	})
+}
+
+// viewer 离线的 Swagger 风格文档页面，不依赖任何外部 js/css 资源
+var viewer = template.Must(template.New("openapi viewer").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 0 auto; max-width: 960px; color: #3b4151; }
+.op { border: 1px solid #ccc; border-radius: 4px; margin: 8px 0; }
+.op > summary { padding: 8px; cursor: pointer; }
+.method { display: inline-block; width: 70px; color: #fff; text-align: center; border-radius: 3px; font-weight: bold; }
+.get { background: #61affe; } .post { background: #49cc90; } .put { background: #fca130; }
+.delete { background: #f93e3e; } .patch { background: #50e3c2; } .head, .options, .trace { background: #9012fe; }
+.path { font-family: monospace; font-weight: bold; margin: 0 8px; }
+.body { padding: 0 12px 12px; }
+pre { background: #f7f7f7; padding: 8px; overflow: auto; }
+table { border-collapse: collapse; } td, th { border-bottom: 1px solid #eee; padding: 4px 12px 4px 0; text-align: left; }
+</style>
+</head>
+<body>
+<h1 id="title">{{.Title}}</h1>
+<p id="description"></p>
+<div id="operations">loading...</div>
+<script>
+var specURL = {{.SpecURL}};
+function el(tag, cls, text) {
+	var e = document.createElement(tag);
+	if (cls) e.className = cls;
+	if (text !== undefined) e.textContent = text;
+	return e;
+}
+function resolve(spec, schema) {
+	if (schema && schema.$ref) {
+		return spec.components.schemas[schema.$ref.replace("#/components/schemas/", "")];
+	}
+	return schema;
+}
+function example(spec, schema, depth) {
+	schema = resolve(spec, schema);
+	if (!schema || depth > 5) return null;
+	switch (schema.type) {
+	case "object":
+		var obj = {};
+		for (var k in (schema.properties || {})) obj[k] = example(spec, schema.properties[k], depth + 1);
+		return obj;
+	case "array": return [example(spec, schema.items, depth + 1)];
+	case "integer": case "number": return 0;
+	case "boolean": return false;
+	default: return schema.format || "string";
+	}
+}
+fetch(specURL).then(function (r) { return r.json(); }).then(function (spec) {
+	document.getElementById("title").textContent = spec.info.title + " " + spec.info.version;
+	document.getElementById("description").textContent = spec.info.description || "";
+	var root = document.getElementById("operations");
+	root.textContent = "";
+	Object.keys(spec.paths).sort().forEach(function (path) {
+		var item = spec.paths[path];
+		Object.keys(item).forEach(function (method) {
+			var op = item[method];
+			var d = el("details", "op");
+			var s = el("summary");
+			s.appendChild(el("span", "method " + method, method.toUpperCase()));
+			s.appendChild(el("span", "path", path));
+			s.appendChild(el("span", "", op.summary || ""));
+			d.appendChild(s);
+			var body = el("div", "body");
+			if (op.parameters && op.parameters.length) {
+				body.appendChild(el("h4", "", "Parameters"));
+				var t = el("table");
+				var head = el("tr");
+				["Name", "In", "Type", "Required", "Description"].forEach(function (h) { head.appendChild(el("th", "", h)); });
+				t.appendChild(head);
+				op.parameters.forEach(function (p) {
+					var tr = el("tr");
+					[p.name, p.in, (p.schema && p.schema.type) || "", p.required ? "yes" : "", p.description || ""].forEach(function (v) {
+						tr.appendChild(el("td", "", v));
+					});
+					t.appendChild(tr);
+				});
+				body.appendChild(t);
+			}
+			if (op.requestBody) {
+				Object.keys(op.requestBody.content).forEach(function (ct) {
+					body.appendChild(el("h4", "", "Request body (" + ct + ")"));
+					body.appendChild(el("pre", "", JSON.stringify(example(spec, op.requestBody.content[ct].schema, 0), null, 2)));
+				});
+			}
+			Object.keys(op.responses).forEach(function (code) {
+				var resp = op.responses[code];
+				body.appendChild(el("h4", "", "Response " + code + " " + resp.description));
+				if (resp.content && resp.content["application/json"]) {
+					body.appendChild(el("pre", "", JSON.stringify(example(spec, resp.content["application/json"].schema, 0), null, 2)));
+				}
+			});
+			d.appendChild(body);
+			root.appendChild(d);
+		});
+	});
+}).catch(function (err) {
+	document.getElementById("operations").textContent = "failed to load " + specURL + ": " + err;
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,484 @@
+// Package openapi 根据 gee 注册的路由和请求/响应结构体生成 OpenAPI 3 文档
+// 参数来源：
+// 1. 路由中的 :param 和 *param 片段，生成 in: path 参数
+// 2. 请求结构体字段的 path/query/header tag，分别生成对应位置的参数
+// 3. 请求结构体字段的 form tag 生成 application/x-www-form-urlencoded 请求体，其余字段按 json tag 生成 application/json 请求体
+// 字段带有 binding:"required" 时标记为必填，doc tag 作为字段描述
+package openapi
+
+import (
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MarkRepo/Gee/Gee/gee"
+)
+
+// Version is the OpenAPI specification version of generated documents
+const Version = "3.0.3"
+
+// Info provides metadata about the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Document is the root object of an OpenAPI document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []*Server           `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components *Components         `json:"components,omitempty"`
+}
+
+// Server describes the host serving the API, the document of a host pattern has one, see GenerateHost
+type Server struct {
+	URL       string                     `json:"url"`
+	Variables map[string]*ServerVariable `json:"variables,omitempty"`
+}
+
+// ServerVariable is a variable for server URL template substitution
+type ServerVariable struct {
+	Enum    []string `json:"enum,omitempty"`
+	Default string   `json:"default"`
+}
+
+// Components holds the reusable schemas referenced by operations
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// PathItem describes the operations available on a single path, keyed by lower case method
+type PathItem map[string]*Operation
+
+// Operation describes a single API operation on a path
+type Operation struct {
+	Summary     string               `json:"summary,omitempty"`
+	OperationID string               `json:"operationId"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter describes a single operation parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes a single request body
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes a single response of an operation
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType provides schema for the media type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is a subset of the JSON schema used by OpenAPI
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// Generate builds the OpenAPI document from the routes registered on e itself, routes of host patterns
+// registered by e.Host are documented separately by GenerateHost, because different hosts may serve the same path
+func Generate(e *gee.Engine, info Info) *Document {
+	return GenerateHost(e, "", info)
+}
+
+// GenerateHost builds the OpenAPI document from the routes of the host pattern registered by e.Host(host),
+// the document has a server describing the host, eg. :tenant.example.com => {scheme}://{tenant}.example.com
+func GenerateHost(e *gee.Engine, host string, info Info) *Document {
+	g := &generator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+		types:   make(map[string]reflect.Type),
+		ids:     make(map[string]bool),
+	}
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+	}
+	// 与 e.Host 相同，忽略大小写和端口
+	host = strings.ToLower(host)
+	if name, port, err := net.SplitHostPort(host); err == nil && strings.Trim(port, "0123456789") == "" {
+		host = name
+	}
+	if host != "" {
+		doc.Servers = []*Server{hostServer(host)}
+	}
+	for _, route := range e.Routes() {
+		// OpenAPI 不支持描述 CONNECT 方法，Mount 挂载的 http.Handler 没有确定的接口
+		if route.Method == http.MethodConnect || route.Mounted || route.Host != host {
+			continue
+		}
+		// OpenAPI 的路径参数都是必填的，可选参数展开为多个路径
+		for _, pattern := range expandOptional(route.Pattern) {
+			path, params := convertPattern(pattern)
+			item, ok := doc.Paths[path]
+			if !ok {
+				item = make(PathItem)
+				doc.Paths[path] = item
+			}
+			item[strings.ToLower(route.Method)] = g.operation(route, pattern, params)
+		}
+	}
+	if len(g.schemas) > 0 {
+		doc.Components = &Components{Schemas: g.schemas}
+	}
+	return doc
+}
+
+// hostServer 将 host 模式转换为 server URL 模板，参数和通配 label 转换为变量，没有名字的通配变量名为 subdomain
+func hostServer(host string) *Server {
+	server := &Server{Variables: map[string]*ServerVariable{
+		"scheme": {Enum: []string{"https", "http"}, Default: "https"},
+	}}
+	labels := strings.Split(host, ".")
+	for i, label := range labels {
+		if label == "" || (label[0] != ':' && label[0] != '*') {
+			continue
+		}
+		name := label[1:]
+		if name == "" {
+			name = "subdomain"
+		}
+		labels[i] = "{" + name + "}"
+		server.Variables[name] = &ServerVariable{Default: name}
+	}
+	server.URL = "{scheme}://" + strings.Join(labels, ".")
+	return server
+}
+
+// expandOptional 展开末尾的可选参数，/docs/:version? => [/docs /docs/:version]
+func expandOptional(pattern string) []string {
+	parts := strings.Split(pattern, "/")
+	required := len(parts)
+	for i := len(parts) - 1; i >= 0; i-- {
+		if !strings.HasPrefix(parts[i], ":") || !strings.HasSuffix(parts[i], "?") {
+			break
+		}
+		parts[i] = strings.TrimSuffix(parts[i], "?")
+		required = i
+	}
+	patterns := make([]string, 0, len(parts)-required+1)
+	for i := required; i <= len(parts); i++ {
+		patterns = append(patterns, strings.Join(parts[:i], "/"))
+	}
+	return patterns
+}
+
+// convertPattern 将 gee 路由转换为 OpenAPI 路径模板，/p/:lang/*filepath => /p/{lang}/{filepath}，并返回路径参数名
+func convertPattern(pattern string) (string, []string) {
+	var params []string
+	parts := strings.Split(pattern, "/")
+	for i, part := range parts {
+		if len(part) > 1 && (part[0] == ':' || part[0] == '*') {
+			params = append(params, part[1:])
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	path := strings.Join(parts, "/")
+	if path == "" {
+		path = "/"
+	}
+	return path, params
+}
+
+type generator struct {
+	schemas map[string]*Schema      // schemas 具名结构体的 schema，通过 $ref 引用
+	names   map[reflect.Type]string // names 结构体对应的 schema 名称
+	types   map[string]reflect.Type // types schema 名称对应的结构体，用于检测不同包中的同名类型
+	ids     map[string]bool         // ids 已经使用的 operationId
+}
+
+// operation 生成 pattern 对应的操作，pattern 是 route.Pattern 展开可选参数后的路由
+func (g *generator) operation(route *gee.RouteInfo, pattern string, pathParams []string) *Operation {
+	op := &Operation{
+		Summary:     route.Summary,
+		OperationID: g.operationID(route.Method, pattern),
+		Responses:   map[string]*Response{},
+	}
+	declared := make(map[string]bool)
+	if route.Request != nil {
+		g.requestParams(op, reflect.TypeOf(route.Request), declared)
+		op.Parameters = dropPathParams(op.Parameters, pathParams)
+	}
+	// 请求结构体中没有声明的路径参数按字符串处理
+	for _, name := range pathParams {
+		if !declared[name] {
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"},
+			})
+		}
+	}
+
+	resp := &Response{Description: http.StatusText(http.StatusOK)}
+	if route.Response != nil {
+		resp.Content = map[string]*MediaType{
+			"application/json": {Schema: g.schema(reflect.TypeOf(route.Response))},
+		}
+	}
+	op.Responses["200"] = resp
+	return op
+}
+
+// requestParams 解析请求结构体，填充参数和请求体，declared 记录已声明的路径参数
+func (g *generator) requestParams(op *Operation, t reflect.Type, declared map[string]bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		op.RequestBody = jsonBody(g.schema(t))
+		return
+	}
+
+	form := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	body := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, f := range fields(t) {
+		required := strings.Contains(f.Tag.Get("binding"), "required")
+		if in, name := paramLocation(f); in != "" {
+			if in == "path" {
+				declared[name] = true
+				required = true
+			}
+			schema := g.schema(f.Type)
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name:        name,
+				In:          in,
+				Description: f.Tag.Get("doc"),
+				Required:    required,
+				Schema:      schema,
+			})
+			continue
+		}
+		target, name := body, jsonName(f)
+		if v, ok := f.Tag.Lookup("form"); ok {
+			target, name = form, strings.Split(v, ",")[0]
+		}
+		if name == "-" {
+			continue
+		}
+		target.Properties[name] = g.fieldSchema(f)
+		if required {
+			target.Required = append(target.Required, name)
+		}
+	}
+
+	content := make(map[string]*MediaType)
+	if len(body.Properties) > 0 {
+		content["application/json"] = &MediaType{Schema: body}
+	}
+	if len(form.Properties) > 0 {
+		content["application/x-www-form-urlencoded"] = &MediaType{Schema: form}
+	}
+	if len(content) > 0 {
+		op.RequestBody = &RequestBody{Required: true, Content: content}
+	}
+}
+
+// dropPathParams 去掉不在当前路径中的路径参数，例如 /docs/:version? 展开后的 /docs 没有 version
+func dropPathParams(params []*Parameter, pathParams []string) []*Parameter {
+	kept := params[:0]
+	for _, p := range params {
+		if p.In == "path" && !contains(pathParams, p.Name) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonBody(schema *Schema) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]*MediaType{"application/json": {Schema: schema}},
+	}
+}
+
+// paramLocation 返回字段对应的参数位置和名称，字段不是参数时 in 为空
+func paramLocation(f reflect.StructField) (in, name string) {
+	for _, loc := range []string{"path", "query", "header"} {
+		if v, ok := f.Tag.Lookup(loc); ok {
+			return loc, strings.Split(v, ",")[0]
+		}
+	}
+	return "", ""
+}
+
+// fields 返回结构体所有导出字段，匿名嵌入的结构体字段会被展开
+func fields(t reflect.Type) []reflect.StructField {
+	var result []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			result = append(result, fields(f.Type)...)
+			continue
+		}
+		if f.PkgPath == "" {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+func jsonName(f reflect.StructField) string {
+	if v, ok := f.Tag.Lookup("json"); ok {
+		if name := strings.Split(v, ",")[0]; name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+func (g *generator) fieldSchema(f reflect.StructField) *Schema {
+	schema := g.schema(f.Type)
+	if desc := f.Tag.Get("doc"); desc != "" && schema.Ref == "" {
+		copied := *schema
+		copied.Description = desc
+		schema = &copied
+	}
+	return schema
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema 根据类型生成 schema，具名结构体放入 components 并返回引用，避免递归类型无限展开
+func (g *generator) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name, ok := g.names[t]
+		if !ok {
+			name = g.schemaName(t)
+			g.names[t], g.types[name] = name, t
+			g.schemas[name] = &Schema{Type: "object"} // placeholder for recursive types
+			g.schemas[name] = g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return &Schema{}
+}
+
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, f := range fields(t) {
+		name := jsonName(f)
+		if name == "-" {
+			continue
+		}
+		s.Properties[name] = g.fieldSchema(f)
+		if strings.Contains(f.Tag.Get("binding"), "required") {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// schemaName 使用 包名.类型名 作为 schema 名称，例如 main.User；
+// 名称已被其他包的同名类型使用时，逐级加上更多的包路径，例如 v2.model.User
+func (g *generator) schemaName(t reflect.Type) string {
+	name := sanitizeName(t.Name())
+	parts := strings.Split(t.PkgPath(), "/")
+	for i := len(parts) - 1; i >= 0; i-- {
+		name = sanitizeName(parts[i]) + "." + name
+		if _, ok := g.types[name]; !ok {
+			return name
+		}
+	}
+	// 包路径完全相同的同名类型只可能是函数内定义的类型
+	for i := 2; ; i++ {
+		if _, ok := g.types[name+"_"+strconv.Itoa(i)]; !ok {
+			return name + "_" + strconv.Itoa(i)
+		}
+	}
+}
+
+// sanitizeName 将 schema 名称中不允许的字符替换为 _，例如泛型类型 Page[main.User]
+func sanitizeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// operationID 根据方法和路由生成唯一的 operationId，例如 GET /p/:lang/doc => get_p_lang_doc；
+// 不同的路由可能生成相同的 id，例如 /a/b 和 /a_b，之后出现的依次加上后缀 _2、_3
+func (g *generator) operationID(method, pattern string) string {
+	id := strings.ToLower(method)
+	for _, part := range strings.Split(pattern, "/") {
+		part = strings.TrimLeft(part, ":*")
+		if part != "" {
+			id += "_" + part
+		}
+	}
+	unique := id
+	for i := 2; g.ids[unique]; i++ {
+		unique = id + "_" + strconv.Itoa(i)
+	}
+	g.ids[unique] = true
+	return unique
+}
@@ -0,0 +1,175 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MarkRepo/Gee/Gee/gee"
+)
+
+type getUserReq struct {
+	ID      int    `path:"id"`
+	Verbose bool   `query:"verbose" doc:"return all fields"`
+	Token   string `header:"X-Token" binding:"required"`
+}
+
+type createUserReq struct {
+	Name string `json:"name" binding:"required"`
+	Age  int    `json:"age"`
+}
+
+type user struct {
+	ID      int     `json:"id"`
+	Name    string  `json:"name"`
+	Friends []*user `json:"friends"`
+}
+
+func TestGenerate(t *testing.T) {
+	r := gee.New()
+	v1 := r.Group("/v1")
+	v1.GET("/users/:id", nil).Doc("get user", getUserReq{}, user{})
+	v1.POST("/users", nil).Doc("create user", &createUserReq{}, &user{})
+	r.GET("/assets/*filepath", nil)
+	r.GET("/docs/:version?", nil)
+
+	doc := Generate(r, Info{Title: "test", Version: "1.0"})
+
+	get := doc.Paths["/v1/users/{id}"]["get"]
+	if get == nil || get.Summary != "get user" || len(get.Parameters) != 3 {
+		t.Fatalf("unexpected GET /v1/users/{id} operation: %+v", get)
+	}
+	for _, p := range get.Parameters {
+		switch p.Name {
+		case "id":
+			if p.In != "path" || !p.Required || p.Schema.Type != "integer" {
+				t.Fatalf("unexpected path parameter %+v", p)
+			}
+		case "X-Token":
+			if p.In != "header" || !p.Required {
+				t.Fatalf("unexpected header parameter %+v", p)
+			}
+		case "verbose":
+			if p.In != "query" || p.Schema.Type != "boolean" || p.Description != "return all fields" {
+				t.Fatalf("unexpected query parameter %+v", p)
+			}
+		}
+	}
+
+	post := doc.Paths["/v1/users"]["post"]
+	body := post.RequestBody.Content["application/json"].Schema
+	if body.Properties["name"].Type != "string" || len(body.Required) != 1 || body.Required[0] != "name" {
+		t.Fatalf("unexpected request body %+v", body)
+	}
+	ref := post.Responses["200"].Content["application/json"].Schema.Ref
+	if ref != "#/components/schemas/openapi.user" {
+		t.Fatalf("unexpected response schema ref %s", ref)
+	}
+	if friends := doc.Components.Schemas["openapi.user"].Properties["friends"]; friends.Items.Ref != ref {
+		t.Fatal("recursive schema should be referenced")
+	}
+
+	assets := doc.Paths["/assets/{filepath}"]["get"]
+	if assets == nil || len(assets.Parameters) != 1 || assets.Parameters[0].In != "path" {
+		t.Fatal("path parameters should be derived from route pattern")
+	}
+	if doc.Paths["/docs"]["get"] == nil || len(doc.Paths["/docs/{version}"]["get"].Parameters) != 1 {
+		t.Fatal("optional parameters should be expanded into separate paths")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	r := gee.New()
+	Register(r, Info{Title: "test", Version: "1.0"})
+	r.GET("/ping", nil).Doc("ping", nil, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs/openapi.json", nil))
+	var doc Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil || doc.Paths["/ping"]["get"].Summary != "ping" {
+		t.Fatal("failed to serve document", err)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Fatal("failed to serve viewer")
+	}
+}
+
+func TestGenerateHost(t *testing.T) {
+	r := gee.New()
+	r.GET("/ping", nil).Doc("ping", nil, nil)
+	r.Host("api.example.com").GET("/ping", nil).Doc("api ping", nil, nil)
+	r.Host(":tenant.example.com").GET("/users/:id", nil)
+	RegisterHost(r, "api.example.com:443", Info{Title: "api", Version: "1.0"})
+
+	if doc := Generate(r, Info{}); doc.Paths["/ping"]["get"].Summary != "ping" || len(doc.Paths) != 1 || doc.Servers != nil {
+		t.Fatalf("engine document should only contain its own routes, got %v", doc.Paths)
+	}
+	doc := GenerateHost(r, ":tenant.example.com", Info{})
+	server := doc.Servers[0]
+	if server.URL != "{scheme}://{tenant}.example.com" || server.Variables["tenant"] == nil || doc.Paths["/users/{id}"]["get"] == nil {
+		t.Fatalf("unexpected host document %+v %v", server, doc.Paths)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/docs/openapi.json", nil)
+	req.Host = "api.example.com"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	doc = &Document{}
+	if err := json.Unmarshal(w.Body.Bytes(), doc); err != nil || doc.Paths["/ping"]["get"].Summary != "api ping" || doc.Servers[0].URL != "{scheme}://api.example.com" {
+		t.Fatal("failed to serve host document", err)
+	}
+}
+
+func TestSchemaNameClash(t *testing.T) {
+	friend := &user{}
+	// 与包级别的 user 同名的类型
+	type user struct {
+		Email string `json:"email"`
+	}
+	r := gee.New()
+	r.GET("/a", nil).Doc("a", nil, &[]user{})
+	r.GET("/c", nil).Doc("c", nil, struct{ Owner *user }{})
+	r.GET("/d", nil).Doc("d", nil, friend)
+
+	doc := Generate(r, Info{})
+	local := doc.Paths["/a"]["get"].Responses["200"].Content["application/json"].Schema.Items.Ref
+	if local != "#/components/schemas/openapi.user" || doc.Components.Schemas["openapi.user"].Properties["email"] == nil {
+		t.Fatalf("unexpected schema %s", local)
+	}
+	pkg := doc.Paths["/d"]["get"].Responses["200"].Content["application/json"].Schema.Ref
+	if pkg != "#/components/schemas/gee.openapi.user" || doc.Components.Schemas["gee.openapi.user"].Properties["friends"] == nil {
+		t.Fatalf("types with the same name should not share a schema, got %s", pkg)
+	}
+	if owner := doc.Paths["/c"]["get"].Responses["200"].Content["application/json"].Schema.Properties["Owner"].Ref; owner != local {
+		t.Fatalf("same type should reuse the schema, got %s", owner)
+	}
+}
+
+func TestOperationID(t *testing.T) {
+	r := gee.New()
+	r.GET("/a/b", nil)
+	r.GET("/a_b", nil)
+	r.GET("/a/:b?", nil)
+	r.Mount("/legacy", http.NotFoundHandler())
+
+	doc := Generate(r, Info{})
+	ids := make(map[string]bool)
+	for path, item := range doc.Paths {
+		for _, op := range item {
+			if ids[op.OperationID] {
+				t.Fatalf("duplicate operationId %s at %s", op.OperationID, path)
+			}
+			ids[op.OperationID] = true
+		}
+	}
+	if len(ids) != 4 || doc.Paths["/a/b"]["get"].OperationID != "get_a_b" || doc.Paths["/a_b"]["get"].OperationID != "get_a_b_2" {
+		t.Fatalf("unexpected operationIds %v", ids)
+	}
+	if doc.Paths["/legacy"] != nil || doc.Paths["/legacy/{filepath}"] != nil {
+		t.Fatal("mounted handlers should not be documented")
+	}
+}
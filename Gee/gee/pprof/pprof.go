@@ -0,0 +1,50 @@
+// Package pprof 将 net/http/pprof 的性能分析接口注册到 gee 的路由分组上
+package pprof
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"path"
+	"strings"
+
+	"github.com/MarkRepo/Gee/Gee/gee"
+)
+
+// DefaultPrefix is the default group prefix of pprof routes
+const DefaultPrefix = "/debug/pprof"
+
+// Register registers the pprof handlers in a new group of r, prefix is DefaultPrefix if omitted.
+// The created group is returned so that middlewares (eg. auth) can be applied to it.
+func Register(r *gee.RouterGroup, prefix ...string) *gee.RouterGroup {
+	p := DefaultPrefix
+	if len(prefix) > 0 {
+		p = prefix[0]
+	}
+	g := r.Group(p)
+	g.GET("/", index)
+	g.GET("/cmdline", gee.WrapF(pprof.Cmdline))
+	g.GET("/profile", gee.WrapF(pprof.Profile))
+	g.GET("/symbol", gee.WrapF(pprof.Symbol))
+	g.POST("/symbol", gee.WrapF(pprof.Symbol))
+	g.GET("/trace", gee.WrapF(pprof.Trace))
+	// pprof.Index 依赖 /debug/pprof/ 前缀解析 profile 名称，自定义前缀时需要逐个注册
+	for _, name := range []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"} {
+		g.GET("/"+name, gee.WrapH(pprof.Handler(name)))
+	}
+	return g
+}
+
+// index 页面中的链接是相对路径，请求路径没有以 / 结尾时重定向到 prefix/，否则链接会指向上一级。
+// Location 使用相对路径，挂载(Mount)后 c.Req.URL.Path 去掉了挂载前缀也能正确跳转
+func index(c *gee.Context) {
+	if p := c.Req.URL.Path; p != "" && !strings.HasSuffix(p, "/") {
+		loc := path.Base(p) + "/"
+		if c.Req.URL.RawQuery != "" {
+			loc += "?" + c.Req.URL.RawQuery
+		}
+		c.SetHeader("Location", loc)
+		c.Status(http.StatusMovedPermanently)
+		return
+	}
+	pprof.Index(c.Writer, c.Req)
+}
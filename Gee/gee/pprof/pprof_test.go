@@ -0,0 +1,53 @@
+package pprof
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/MarkRepo/Gee/Gee/gee"
+)
+
+var linkRe = regexp.MustCompile(`href="(goroutine\?debug=2)"`)
+
+// get 请求 url 并跟随重定向，返回响应体和最终的 url
+func get(t *testing.T, url string) (string, string) {
+	t.Helper()
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: unexpected status %d", url, res.StatusCode)
+	}
+	return string(body), res.Request.URL.String()
+}
+
+func TestIndexLinks(t *testing.T) {
+	inner := gee.New()
+	Register(inner.RouterGroup, "/admin/pprof")
+	r := gee.New()
+	Register(r.RouterGroup)
+	r.Mount("/ops", inner)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	for _, index := range []string{"/debug/pprof", "/debug/pprof/", "/ops/admin/pprof"} {
+		body, final := get(t, srv.URL+index)
+		if !strings.HasSuffix(final, "/") {
+			t.Fatalf("%s should be redirected to a path ending with /, got %s", index, final)
+		}
+		m := linkRe.FindStringSubmatch(body)
+		if m == nil {
+			t.Fatalf("%s: goroutine link not found in index", index)
+		}
+		if body, _ = get(t, final+m[1]); !strings.Contains(body, "goroutine 1 [") {
+			t.Fatalf("%s: following the goroutine link returns %q", index, body)
+		}
+	}
+}
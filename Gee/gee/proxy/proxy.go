@@ -0,0 +1,337 @@
+// Package proxy 实现反向代理 handler，将匹配到的路由转发到一组上游服务
+// 1. 通配参数(默认 *filepath)匹配到的剩余路径会拼接到上游地址后面
+// 2. 支持轮询和最少连接两种负载均衡策略
+// 3. 可选的主动健康检查，不健康的上游不会被选中
+// 4. 幂等请求在上游连接失败且尚未写出响应时，会换一个上游重试
+// 5. 基于 httputil.ReverseProxy，WebSocket(Upgrade) 和 SSE 响应可以直接透传
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MarkRepo/Gee/Gee/gee"
+)
+
+// SelectMode 代表不同的负载均衡策略
+type SelectMode int
+
+const (
+	RoundRobinSelect SelectMode = iota // select using round robin algorithm
+	LeastConnSelect                    // select the upstream with the least active connections
+)
+
+const (
+	defaultParam               = "filepath"
+	defaultHealthCheckInterval = time.Second * 10
+	defaultHealthCheckTimeout  = time.Second * 2
+)
+
+// ErrNoUpstream is returned when there is no healthy upstream
+var ErrNoUpstream = errors.New("proxy: no healthy upstream")
+
+// Options 反向代理配置
+type Options struct {
+	Targets []string   // Targets 上游地址，例如 http://10.0.0.1:8080/api
+	Mode    SelectMode // Mode 负载均衡策略
+	Param   string     // Param 通配参数名，其值作为转发路径，默认 filepath；路由中没有该参数时转发原始路径
+
+	HealthCheckPath     string        // HealthCheckPath 健康检查路径，为空时不做主动健康检查
+	HealthCheckInterval time.Duration // HealthCheckInterval 健康检查间隔，默认 10s
+	HealthCheckTimeout  time.Duration // HealthCheckTimeout 单次健康检查超时，默认 2s
+
+	Retries int // Retries 幂等请求失败后换上游重试的次数
+
+	PreserveHost    bool              // PreserveHost 保留原始 Host 头，默认使用上游的 Host
+	RequestHeaders  map[string]string // RequestHeaders 转发前设置的请求头，值为空表示删除
+	ResponseHeaders map[string]string // ResponseHeaders 返回前设置的响应头，值为空表示删除
+
+	FlushInterval time.Duration     // FlushInterval 见 httputil.ReverseProxy，text/event-stream 总是立即刷新
+	Transport     http.RoundTripper // Transport 转发使用的 RoundTripper，默认 http.DefaultTransport
+}
+
+// Proxy forwards requests to a pool of upstreams
+type Proxy struct {
+	opt       Options
+	upstreams []*upstream
+	index     uint64 // index 轮询计数
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// upstream 单个上游服务
+type upstream struct {
+	target  *url.URL
+	proxy   *httputil.ReverseProxy
+	healthy int32 // healthy 1 表示健康，atomic
+	conns   int64 // conns 正在处理的请求数，atomic
+}
+
+func (u *upstream) isHealthy() bool {
+	return atomic.LoadInt32(&u.healthy) == 1
+}
+
+func (u *upstream) setHealthy(healthy bool) {
+	var v int32
+	if healthy {
+		v = 1
+	}
+	if atomic.SwapInt32(&u.healthy, v) != v {
+		log.Printf("proxy: upstream %s healthy: %v", u.target, healthy)
+	}
+}
+
+// attemptKey 在请求 context 中保存本次转发的结果
+type attemptKey struct{}
+
+type attempt struct {
+	err error
+}
+
+// New creates a Proxy and starts the health checks if HealthCheckPath is set
+func New(opt Options) (*Proxy, error) {
+	if len(opt.Targets) == 0 {
+		return nil, errors.New("proxy: no target")
+	}
+	if opt.Param == "" {
+		opt.Param = defaultParam
+	}
+	if opt.HealthCheckInterval == 0 {
+		opt.HealthCheckInterval = defaultHealthCheckInterval
+	}
+	if opt.HealthCheckTimeout == 0 {
+		opt.HealthCheckTimeout = defaultHealthCheckTimeout
+	}
+	p := &Proxy{opt: opt, done: make(chan struct{})}
+	for _, target := range opt.Targets {
+		u, err := url.Parse(target)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("proxy: invalid target %q", target)
+		}
+		p.upstreams = append(p.upstreams, p.newUpstream(u))
+	}
+	if opt.HealthCheckPath != "" {
+		go p.healthCheck()
+	}
+	return p, nil
+}
+
+func (p *Proxy) newUpstream(target *url.URL) *upstream {
+	u := &upstream{target: target, healthy: 1}
+	u.proxy = &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = target.Scheme
+			req.URL.Host = target.Host
+			req.URL.Path, req.URL.RawPath = joinPath(target, req.URL)
+			if !p.opt.PreserveHost {
+				req.Host = target.Host
+			}
+			for k, v := range p.opt.RequestHeaders {
+				if v == "" {
+					req.Header.Del(k)
+				} else {
+					req.Header.Set(k, v)
+				}
+			}
+		},
+		Transport:     p.opt.Transport,
+		FlushInterval: p.opt.FlushInterval,
+		ModifyResponse: func(resp *http.Response) error {
+			for k, v := range p.opt.ResponseHeaders {
+				if v == "" {
+					resp.Header.Del(k)
+				} else {
+					resp.Header.Set(k, v)
+				}
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			// 不直接写响应，由 Handler 决定重试还是返回错误
+			if a, ok := req.Context().Value(attemptKey{}).(*attempt); ok {
+				a.err = err
+			}
+		},
+	}
+	return u
+}
+
+// Close stops the health checks
+func (p *Proxy) Close() {
+	p.closeOnce.Do(func() { close(p.done) })
+}
+
+// Handler returns the handler forwarding requests to the upstreams
+func (p *Proxy) Handler() gee.HandlerFunc {
+	return func(c *gee.Context) {
+		req := c.Req.Clone(c.Req.Context())
+		if path, ok := c.Params[p.opt.Param]; ok {
+			req.URL.Path = "/" + path
+			req.URL.RawPath = ""
+		}
+		req.Header.Set("X-Forwarded-Host", c.Req.Host)
+		if c.Req.TLS != nil {
+			req.Header.Set("X-Forwarded-Proto", "https")
+		} else {
+			req.Header.Set("X-Forwarded-Proto", "http")
+		}
+
+		retries := 0
+		if isIdempotent(req) {
+			retries = p.opt.Retries
+		}
+		w := &responseWriter{ResponseWrapper: gee.ResponseWrapper{ResponseWriter: c.Writer}}
+		tried := make(map[*upstream]bool)
+		var err error
+		for i := 0; i <= retries; i++ {
+			u := p.pick(tried)
+			if u == nil {
+				if err == nil {
+					err = ErrNoUpstream
+				}
+				break
+			}
+			tried[u] = true
+			if err = p.forward(u, w, req); err == nil || w.written {
+				break
+			}
+			log.Printf("proxy: forward %s %s to %s failed: %v", req.Method, req.URL.Path, u.target, err)
+		}
+
+		switch {
+		case err == nil || w.written:
+		case errors.Is(err, ErrNoUpstream):
+			c.String(http.StatusServiceUnavailable, "%s\n", err)
+		case errors.Is(err, context.Canceled):
+			// client gone, nothing to respond
+		default:
+			c.String(http.StatusBadGateway, "proxy: %s\n", http.StatusText(http.StatusBadGateway))
+		}
+	}
+}
+
+// forward 将请求转发给上游 u，返回转发错误
+func (p *Proxy) forward(u *upstream, w *responseWriter, req *http.Request) error {
+	atomic.AddInt64(&u.conns, 1)
+	defer atomic.AddInt64(&u.conns, -1)
+
+	a := &attempt{}
+	u.proxy.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), attemptKey{}, a)))
+	return a.err
+}
+
+// pick 根据负载均衡策略选择一个健康且未尝试过的上游，没有可用上游时返回 nil
+func (p *Proxy) pick(tried map[*upstream]bool) *upstream {
+	n := len(p.upstreams)
+	start := int(atomic.AddUint64(&p.index, 1) % uint64(n))
+	var best *upstream
+	for i := 0; i < n; i++ {
+		u := p.upstreams[(start+i)%n]
+		if tried[u] || !u.isHealthy() {
+			continue
+		}
+		if p.opt.Mode == RoundRobinSelect {
+			return u
+		}
+		if best == nil || atomic.LoadInt64(&u.conns) < atomic.LoadInt64(&best.conns) {
+			best = u
+		}
+	}
+	return best
+}
+
+// healthCheck 定期探测所有上游
+func (p *Proxy) healthCheck() {
+	client := &http.Client{Timeout: p.opt.HealthCheckTimeout, Transport: p.opt.Transport}
+	t := time.NewTicker(p.opt.HealthCheckInterval)
+	defer t.Stop()
+	for {
+		var wg sync.WaitGroup
+		for _, u := range p.upstreams {
+			wg.Add(1)
+			go func(u *upstream) {
+				defer wg.Done()
+				u.setHealthy(probe(client, u.target, p.opt.HealthCheckPath))
+			}(u)
+		}
+		wg.Wait()
+		select {
+		case <-p.done:
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func probe(client *http.Client, target *url.URL, path string) bool {
+	u := *target
+	u.Path = singleJoiningSlash(target.Path, path)
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return false
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode < http.StatusBadRequest
+}
+
+// isIdempotent 幂等且请求体可以重复发送的请求才允许重试
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return req.Body == nil || req.Body == http.NoBody || req.ContentLength == 0 && req.Header.Get("Transfer-Encoding") == ""
+	}
+	return false
+}
+
+// joinPath 将请求路径拼接到上游路径后
+func joinPath(target, u *url.URL) (path, rawPath string) {
+	if target.RawPath == "" && u.RawPath == "" {
+		return singleJoiningSlash(target.Path, u.Path), ""
+	}
+	return singleJoiningSlash(target.Path, u.Path), singleJoiningSlash(target.EscapedPath(), u.EscapedPath())
+}
+
+func singleJoiningSlash(a, b string) string {
+	aSlash := strings.HasSuffix(a, "/")
+	bSlash := strings.HasPrefix(b, "/")
+	switch {
+	case aSlash && bSlash:
+		return a + b[1:]
+	case !aSlash && !bSlash:
+		return a + "/" + b
+	}
+	return a + b
+}
+
+// responseWriter 记录是否已经向客户端写出响应，写出后不能再重试
+type responseWriter struct {
+	gee.ResponseWrapper
+	written bool
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	w.written = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Hijack 用于 WebSocket 等协议升级的透传
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.written = true
+	return w.ResponseWrapper.Hijack()
+}
@@ -0,0 +1,119 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MarkRepo/Gee/Gee/gee"
+)
+
+func newUpstream(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			return
+		}
+		w.Header().Set("X-Internal", "secret")
+		_, _ = fmt.Fprintf(w, "%s %s %s %s", name, r.URL.Path, r.URL.RawQuery, r.Header.Get("X-Gateway"))
+	}))
+}
+
+func serve(r *gee.Engine, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestProxy(t *testing.T) {
+	a, b := newUpstream("a"), newUpstream("b")
+	defer a.Close()
+	defer b.Close()
+
+	p, err := New(Options{
+		Targets:         []string{a.URL + "/v1", b.URL + "/v1"},
+		RequestHeaders:  map[string]string{"X-Gateway": "gee"},
+		ResponseHeaders: map[string]string{"X-Internal": ""},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	r := gee.New()
+	r.GET("/legacy/*filepath", p.Handler())
+
+	seen := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		w := serve(r, http.MethodGet, "/legacy/users/1?verbose=1")
+		body := w.Body.String()
+		if body[2:] != "/v1/users/1 verbose=1 gee" || w.Header().Get("X-Internal") != "" {
+			t.Fatalf("unexpected response %q", body)
+		}
+		seen[body[:1]]++
+	}
+	if seen["a"] != 2 || seen["b"] != 2 {
+		t.Fatal("requests should be balanced with round robin, got", seen)
+	}
+}
+
+func TestProxyRetry(t *testing.T) {
+	a := newUpstream("a")
+	defer a.Close()
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	p, _ := New(Options{Targets: []string{dead.URL, a.URL}, Retries: 1})
+	defer p.Close()
+	r := gee.New()
+	r.GET("/*filepath", p.Handler())
+	r.POST("/*filepath", p.Handler())
+
+	for i := 0; i < 2; i++ {
+		if w := serve(r, http.MethodGet, "/x"); w.Code != http.StatusOK {
+			t.Fatalf("idempotent request should be retried, got %d", w.Code)
+		}
+	}
+	codes := make(map[int]int)
+	for i := 0; i < 2; i++ {
+		codes[serve(r, http.MethodPost, "/x").Code]++
+	}
+	if codes[http.StatusBadGateway] != 1 {
+		t.Fatal("non idempotent request should not be retried, got", codes)
+	}
+}
+
+func TestProxyHealthCheck(t *testing.T) {
+	a, b := newUpstream("a"), newUpstream("b")
+	defer a.Close()
+	b.Close()
+
+	p, _ := New(Options{
+		Targets:             []string{a.URL, b.URL},
+		Mode:                LeastConnSelect,
+		HealthCheckPath:     "/health",
+		HealthCheckInterval: time.Millisecond * 10,
+	})
+	defer p.Close()
+	r := gee.New()
+	r.GET("/*filepath", p.Handler())
+
+	deadline := time.Now().Add(time.Second)
+	for p.upstreams[1].isHealthy() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+	for i := 0; i < 4; i++ {
+		if w := serve(r, http.MethodGet, "/x"); w.Code != http.StatusOK || w.Body.String()[:1] != "a" {
+			t.Fatalf("unhealthy upstream should not be picked, got %d %q", w.Code, w.Body.String())
+		}
+	}
+
+	a.Close()
+	deadline = time.Now().Add(time.Second)
+	for p.upstreams[0].isHealthy() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+	if w := serve(r, http.MethodGet, "/x"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expect 503 without healthy upstream, got %d", w.Code)
+	}
+}
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// State 单个 key 的限流状态，字段含义由算法解释，Store 只负责保存
+type State struct {
+	Count float64   // Count 令牌桶：剩余令牌数；滑动窗口：当前窗口请求数
+	Prev  float64   // Prev 滑动窗口：上一个窗口请求数
+	Last  time.Time // Last 令牌桶：上次补充令牌的时间；滑动窗口：当前窗口开始时间
+}
+
+// Result 一次限流判断的结果
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // Reset 额度完全恢复(令牌桶)或当前窗口结束(滑动窗口)的时间
+	RetryAfter time.Duration // RetryAfter 被拒绝时，距离下一次可能成功的时间
+}
+
+// Algorithm 限流算法，Take 根据当前状态判断是否允许本次请求并更新状态
+type Algorithm interface {
+	Take(s *State, now time.Time) Result
+	// TTL 状态保存的时长，超过该时长没有请求时状态等同于初始状态
+	TTL() time.Duration
+}
+
+// TokenBucket allows bursts of limit requests, tokens are refilled at limit per period
+func TokenBucket(limit int, period time.Duration) Algorithm {
+	return &tokenBucket{limit: float64(limit), period: period}
+}
+
+type tokenBucket struct {
+	limit  float64
+	period time.Duration
+}
+
+func (b *tokenBucket) TTL() time.Duration {
+	return b.period
+}
+
+func (b *tokenBucket) Take(s *State, now time.Time) Result {
+	if s.Last.IsZero() {
+		s.Count, s.Last = b.limit, now
+	}
+	if elapsed := now.Sub(s.Last); elapsed > 0 {
+		s.Count = math.Min(b.limit, s.Count+elapsed.Seconds()*b.limit/b.period.Seconds())
+		s.Last = now
+	}
+	r := Result{Limit: int(b.limit)}
+	if s.Count >= 1 {
+		s.Count--
+		r.Allowed = true
+	} else {
+		r.RetryAfter = b.duration(1 - s.Count)
+	}
+	r.Remaining = int(s.Count)
+	r.Reset = b.duration(b.limit - s.Count)
+	return r
+}
+
+// duration 补充 tokens 个令牌需要的时间
+func (b *tokenBucket) duration(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens * float64(b.period) / b.limit))
+}
+
+// SlidingWindow allows limit requests in any window, the count of the previous fixed window is weighted
+// by its overlap with the sliding window, so it uses constant memory per key
+func SlidingWindow(limit int, window time.Duration) Algorithm {
+	return &slidingWindow{limit: float64(limit), window: window}
+}
+
+type slidingWindow struct {
+	limit  float64
+	window time.Duration
+}
+
+func (w *slidingWindow) TTL() time.Duration {
+	return 2 * w.window
+}
+
+func (w *slidingWindow) Take(s *State, now time.Time) Result {
+	start := now.Truncate(w.window)
+	if !s.Last.Equal(start) {
+		if s.Last.Equal(start.Add(-w.window)) {
+			s.Prev = s.Count
+		} else {
+			s.Prev = 0
+		}
+		s.Count, s.Last = 0, start
+	}
+	elapsed := float64(now.Sub(start)) / float64(w.window)
+	estimate := s.Prev*(1-elapsed) + s.Count
+
+	r := Result{Limit: int(w.limit), Reset: start.Add(w.window).Sub(now)}
+	if estimate+1 <= w.limit {
+		s.Count++
+		estimate++
+		r.Allowed = true
+	} else if room := w.limit - 1 - s.Count; room >= 0 && s.Prev > 0 {
+		// 上一个窗口的权重下降到 room 时可以再次通过
+		at := 1 - room/s.Prev
+		r.RetryAfter = time.Duration(math.Ceil((at - elapsed) * float64(w.window)))
+	} else {
+		r.RetryAfter = r.Reset
+	}
+	r.Remaining = int(math.Max(0, w.limit-estimate))
+	return r
+}
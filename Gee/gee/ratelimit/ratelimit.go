@@ -0,0 +1,114 @@
+// Package ratelimit 实现限流中间件
+// 1. 算法：令牌桶(TokenBucket)和滑动窗口(SlidingWindow)
+// 2. 限流 key：客户端 IP、请求头(例如 API Key)、路由参数，也可以自定义 KeyFunc
+// 3. 状态保存在 Store 中，默认使用分片加锁的内存 Store，实现 Store 接口即可在多个实例间共享限额
+// 4. 响应携带 RateLimit-Limit、RateLimit-Remaining、RateLimit-Reset 头，被拒绝时返回 429 和 Retry-After
+package ratelimit
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MarkRepo/Gee/Gee/gee"
+)
+
+// defaultMaxKeys 默认 Store 最多保存的 key 数量
+const defaultMaxKeys = 100000
+
+// KeyFunc returns the key the request is limited by
+type KeyFunc func(c *gee.Context) string
+
+// ClientIP limits requests by c.ClientIP()
+func ClientIP() KeyFunc {
+	return func(c *gee.Context) string {
+		return c.ClientIP()
+	}
+}
+
+// Header limits requests by the value of the request header, eg. X-API-Key
+func Header(name string) KeyFunc {
+	return func(c *gee.Context) string {
+		return c.Req.Header.Get(name)
+	}
+}
+
+// Param limits requests by the value of the route parameter
+func Param(name string) KeyFunc {
+	return func(c *gee.Context) string {
+		return c.Param(name)
+	}
+}
+
+// Options 限流配置
+type Options struct {
+	Algorithm Algorithm // Algorithm 限流算法，必填
+	KeyFunc   KeyFunc   // KeyFunc 默认 ClientIP；返回空字符串时使用客户端 IP，避免不带 key 的请求绕过限流
+	Store     Store     // Store 默认使用 NewMemoryStore(0, defaultMaxKeys)，不启动清理 goroutine，无需 Close
+	Prefix    string    // Prefix 保存到 Store 的 key 前缀，多个中间件共享 Store 时用于区分，默认 ratelimit:
+
+	Skip         func(*gee.Context) bool    // Skip 返回 true 时不限流
+	ErrorHandler func(*gee.Context, Result) // ErrorHandler 请求被拒绝时调用，默认返回 429
+}
+
+// New returns the rate limiting middleware
+func New(opt Options) gee.HandlerFunc {
+	if opt.Algorithm == nil {
+		panic("ratelimit: Algorithm is required")
+	}
+	if opt.KeyFunc == nil {
+		opt.KeyFunc = ClientIP()
+	}
+	if opt.Store == nil {
+		opt.Store = NewMemoryStore(0, defaultMaxKeys)
+	}
+	if opt.Prefix == "" {
+		opt.Prefix = "ratelimit:"
+	}
+	if opt.ErrorHandler == nil {
+		opt.ErrorHandler = func(c *gee.Context, r Result) {
+			c.String(http.StatusTooManyRequests, "%s\n", http.StatusText(http.StatusTooManyRequests))
+		}
+	}
+
+	return func(c *gee.Context) {
+		if opt.Skip != nil && opt.Skip(c) {
+			c.Next()
+			return
+		}
+		key := opt.KeyFunc(c)
+		if key == "" {
+			key = c.ClientIP()
+		}
+		var r Result
+		now := time.Now()
+		err := opt.Store.Update(opt.Prefix+key, opt.Algorithm.TTL(), func(s *State) {
+			r = opt.Algorithm.Take(s, now)
+		})
+		if err != nil {
+			// Store 不可用时放行，避免限流组件故障导致服务不可用
+			log.Printf("ratelimit: update %s failed: %v", key, err)
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(r.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(r.Remaining))
+		header.Set("RateLimit-Reset", seconds(r.Reset))
+		if !r.Allowed {
+			header.Set("Retry-After", seconds(r.RetryAfter))
+			opt.ErrorHandler(c, r)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// seconds 向上取整到秒
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MarkRepo/Gee/Gee/gee"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b := TokenBucket(2, time.Second)
+	var s State
+	now := time.Now()
+	for i, allowed := range []bool{true, true, false} {
+		if r := b.Take(&s, now); r.Allowed != allowed {
+			t.Fatalf("request %d: expect allowed %v", i, allowed)
+		} else if !allowed && r.RetryAfter != 500*time.Millisecond {
+			t.Fatalf("expect retry after 500ms, got %v", r.RetryAfter)
+		}
+	}
+	if r := b.Take(&s, now.Add(500*time.Millisecond)); !r.Allowed || r.Remaining != 0 {
+		t.Fatalf("one token should be refilled after 500ms, got %+v", r)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	w := SlidingWindow(4, time.Minute)
+	var s State
+	start := time.Now().Truncate(time.Minute)
+	for i := 0; i < 4; i++ {
+		if !w.Take(&s, start).Allowed {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+	if r := w.Take(&s, start.Add(30*time.Second)); r.Allowed || r.Reset != 30*time.Second {
+		t.Fatalf("expect rejected with 30s reset, got %+v", r)
+	}
+	// 下一个窗口过去一半时，上一个窗口的 4 个请求按 2 个计算
+	next := start.Add(90 * time.Second)
+	for i, allowed := range []bool{true, true, false} {
+		if w.Take(&s, next).Allowed != allowed {
+			t.Fatalf("request %d in next window: expect allowed %v", i, allowed)
+		}
+	}
+}
+
+func TestMemoryStoreEvict(t *testing.T) {
+	s := NewMemoryStore(0, shardCount)
+	for i := 0; i < shardCount*4; i++ {
+		_ = s.Update(string(rune('a'+i)), time.Minute, func(*State) {})
+	}
+	if n := s.Len(); n > shardCount {
+		t.Fatalf("expect at most %d keys, got %d", shardCount, n)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	r := gee.New()
+	r.Use(New(Options{Algorithm: TokenBucket(1, time.Minute), KeyFunc: Header("X-API-Key")}))
+	r.GET("/", func(c *gee.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	serve := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := serve("a"); w.Code != http.StatusOK || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+	w := serve("a")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" || w.Body.String() == "ok" {
+		t.Fatalf("expect 429 with Retry-After 60, got %d %v", w.Code, w.Header())
+	}
+	if w := serve("b"); w.Code != http.StatusOK {
+		t.Fatalf("other keys should not be limited, got %d", w.Code)
+	}
+}
@@ -0,0 +1,135 @@
+package ratelimit
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// Store 保存限流状态，多个实例共享同一个 Store(例如基于 Redis 实现)即可共享限额
+type Store interface {
+	// Update atomically applies fn to the state of key, a new key starts with the zero State.
+	// The state expires if the key is not updated within ttl.
+	Update(key string, ttl time.Duration, fn func(s *State)) error
+}
+
+const shardCount = 64
+
+// MemoryStore is an in-memory Store, keys are spread over shards to reduce lock contention
+type MemoryStore struct {
+	shards    [shardCount]*shard
+	maxKeys   int // maxKeys 每个分片最多保存的 key 数量，0 表示不限制
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type shard struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+type entry struct {
+	state   State
+	expires time.Time
+}
+
+// NewMemoryStore creates a MemoryStore, expired keys are removed every cleanupInterval.
+// maxKeys limits the number of keys, 0 means no limit; when it is reached, expired keys are removed
+// first, then the keys closest to expiring are evicted.
+func NewMemoryStore(cleanupInterval time.Duration, maxKeys int) *MemoryStore {
+	s := &MemoryStore{done: make(chan struct{})}
+	if maxKeys > 0 {
+		s.maxKeys = (maxKeys + shardCount - 1) / shardCount
+	}
+	for i := range s.shards {
+		s.shards[i] = &shard{entries: make(map[string]*entry)}
+	}
+	if cleanupInterval > 0 {
+		go s.janitor(cleanupInterval)
+	}
+	return s
+}
+
+func (s *MemoryStore) shard(key string) *shard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return s.shards[h.Sum32()%shardCount]
+}
+
+// Update implements Store
+func (s *MemoryStore) Update(key string, ttl time.Duration, fn func(s *State)) error {
+	now := time.Now()
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	e, ok := sh.entries[key]
+	if !ok || now.After(e.expires) {
+		if !ok && s.maxKeys > 0 && len(sh.entries) >= s.maxKeys {
+			sh.evict(now)
+		}
+		e = &entry{}
+		sh.entries[key] = e
+	}
+	fn(&e.state)
+	e.expires = now.Add(ttl)
+	return nil
+}
+
+// Len returns the number of keys in the store
+func (s *MemoryStore) Len() int {
+	n := 0
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		n += len(sh.entries)
+		sh.mu.Unlock()
+	}
+	return n
+}
+
+// Close stops the cleanup goroutine
+func (s *MemoryStore) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+func (s *MemoryStore) janitor(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-t.C:
+			for _, sh := range s.shards {
+				sh.mu.Lock()
+				sh.removeExpired(now)
+				sh.mu.Unlock()
+			}
+		}
+	}
+}
+
+func (sh *shard) removeExpired(now time.Time) int {
+	n := 0
+	for k, e := range sh.entries {
+		if now.After(e.expires) {
+			delete(sh.entries, k)
+			n++
+		}
+	}
+	return n
+}
+
+// evict 为新 key 腾出空间，优先删除过期的 key，否则删除最早过期的 key
+func (sh *shard) evict(now time.Time) {
+	if sh.removeExpired(now) > 0 {
+		return
+	}
+	var oldest string
+	var expires time.Time
+	for k, e := range sh.entries {
+		if oldest == "" || e.expires.Before(expires) {
+			oldest, expires = k, e.expires
+		}
+	}
+	delete(sh.entries, oldest)
+}
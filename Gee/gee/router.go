@@ -0,0 +1,152 @@
+package gee
+
+import (
+	"log"
+	"net/http"
+	"strings"
+)
+
+type router struct {
+	roots    map[string]*node       // roots key eg, roots['GET'] roots['POST']
+	handlers map[string]HandlerFunc // handlers key eg, handlers['GET-/p/:lang/doc'], handlers['POST-/p/book']
+	routes   map[string]*RouteInfo  // routes 与 handlers 使用相同的 key，用于判断路由是否被禁用
+}
+
+func newRouter() *router {
+	return &router{
+		roots:    make(map[string]*node),
+		handlers: make(map[string]HandlerFunc),
+		routes:   make(map[string]*RouteInfo),
+	}
+}
+
+// parsePattern only one * is allowed，* 之后只能是静态片段，否则忽略 * 之后的部分
+// eg. /files/*path/raw => [files *path raw]，/p/*name/* => [p *name]
+func parsePattern(pattern string) []string {
+	items := strings.Split(pattern, "/")
+	parts := make([]string, 0)
+	for i, item := range items {
+		if item != "" {
+			parts = append(parts, item)
+			if item[0] == '*' {
+				if staticSuffix(items[i+1:]) {
+					for _, suffix := range items[i+1:] {
+						if suffix != "" {
+							parts = append(parts, suffix)
+						}
+					}
+				}
+				break
+			}
+		}
+	}
+	return parts
+}
+
+func staticSuffix(items []string) bool {
+	for _, item := range items {
+		if item != "" && (item[0] == ':' || item[0] == '*') {
+			return false
+		}
+	}
+	return true
+}
+
+// expandOptional 展开末尾的可选参数，/docs/:version? => [docs] [docs :version]
+func expandOptional(pattern string, parts []string) [][]string {
+	required := len(parts)
+	for i, part := range parts {
+		if part[0] == ':' && strings.HasSuffix(part, "?") {
+			if required == len(parts) {
+				required = i
+			}
+			parts[i] = strings.TrimSuffix(part, "?")
+		} else if required != len(parts) {
+			panic("gee: optional parameters must be at the end of route " + pattern)
+		}
+	}
+	expanded := make([][]string, 0, len(parts)-required+1)
+	for i := required; i <= len(parts); i++ {
+		expanded = append(expanded, parts[:i])
+	}
+	return expanded
+}
+
+func (r *router) addRoute(method, pattern string, handler HandlerFunc) {
+	log.Printf("Route %4s - %s", method, pattern)
+	key := method + "-" + pattern
+	if _, ok := r.roots[method]; !ok {
+		r.roots[method] = &node{}
+	}
+	for _, parts := range expandOptional(pattern, parsePattern(pattern)) {
+		r.roots[method].insert(pattern, parts, 0)
+	}
+	r.handlers[key] = handler
+}
+
+// 解析了:和*两种匹配符的参数，返回一个 map
+func (r *router) getRoute(method, path string) (*node, map[string]string) {
+	root, ok := r.roots[method]
+	if !ok {
+		return nil, nil
+	}
+
+	searchParts := splitPath(path)
+	n := root.search(searchParts, 0)
+	if n == nil {
+		return nil, nil
+	}
+
+	params := make(map[string]string)
+	for i, part := range n.parts {
+		if part[0] == ':' && len(part) > 1 {
+			params[part[1:]] = searchParts[i]
+		}
+		if part[0] == '*' {
+			// 通配匹配除静态后缀以外的部分，可以为空
+			end := len(searchParts) - (len(n.parts) - i - 1)
+			if len(part) > 1 {
+				params[part[1:]] = strings.Join(searchParts[i:end], "/")
+			}
+			break
+		}
+	}
+	return n, params
+}
+
+// splitPath 将请求路径按 / 切分，忽略空片段
+func splitPath(path string) []string {
+	parts := make([]string, 0)
+	for _, item := range strings.Split(path, "/") {
+		if item != "" {
+			parts = append(parts, item)
+		}
+	}
+	return parts
+}
+
+func (r *router) handle(c *Context) {
+	n, params := r.getRoute(c.Method, c.Path)
+	if n != nil {
+		if route, ok := r.routes[c.Method+"-"+n.pattern]; ok && !route.Enabled() {
+			n = nil
+		}
+	}
+	if n != nil {
+		// 合并 host 参数，同名时路径参数优先
+		for k, v := range c.Params {
+			if _, ok := params[k]; !ok {
+				params[k] = v
+			}
+		}
+		c.Params = params
+		c.Pattern = n.pattern
+		key := c.Method + "-" + n.pattern
+		c.handlers = append(c.handlers, r.handlers[key])
+	} else {
+		c.handlers = append(c.handlers, func(c *Context) {
+			c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Path)
+		})
+	}
+	c.Next()
+}
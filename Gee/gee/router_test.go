@@ -0,0 +1,107 @@
+package gee
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func newTestRouter() *router {
+	r := newRouter()
+	r.addRoute("GET", "/", nil)
+	r.addRoute("GET", "/hello/:name", nil)
+	r.addRoute("GET", "/hello/b/c", nil)
+	r.addRoute("GET", "/hi/:name", nil)
+	r.addRoute("GET", "/assets/*filepath", nil)
+	return r
+}
+
+func TestParsePattern(t *testing.T) {
+	ok := reflect.DeepEqual(parsePattern("/p/:name"), []string{"p", ":name"})
+	ok = ok && reflect.DeepEqual(parsePattern("/p/*"), []string{"p", "*"})
+	ok = ok && reflect.DeepEqual(parsePattern("/p/*name/*"), []string{"p", "*name"})
+	ok = ok && reflect.DeepEqual(parsePattern("/files/*path/raw"), []string{"files", "*path", "raw"})
+	if !ok {
+		t.Fatal("test parsePattern failed")
+	}
+}
+
+func TestGetRoute(t *testing.T) {
+	r := newTestRouter()
+	n, ps := r.getRoute("GET", "/hello/geektutu")
+
+	if n == nil {
+		t.Fatal("nil shouldn't be returned")
+	}
+
+	if n.pattern != "/hello/:name" {
+		t.Fatal("should match /hello/:name")
+	}
+
+	if ps["name"] != "geektutu" {
+		t.Fatal("name should be equal to 'geektutu'")
+	}
+
+	fmt.Printf("matched path: %s, params['name']: %s\n", n.pattern, ps["name"])
+
+}
+
+func TestGetRouteOptional(t *testing.T) {
+	r := newRouter()
+	r.addRoute("GET", "/docs/:version?/:page?", nil)
+	r.addRoute("GET", "/assets/*filepath", nil)
+	r.addRoute("GET", "/files/*path/raw", nil)
+	r.addRoute("GET", "/files/*path", nil)
+	r.addRoute("GET", "/files/new", nil)
+
+	tests := []struct {
+		path    string
+		pattern string
+		params  map[string]string
+	}{
+		{"/docs", "/docs/:version?/:page?", map[string]string{}},
+		{"/docs/v1", "/docs/:version?/:page?", map[string]string{"version": "v1"}},
+		{"/docs/v1/intro", "/docs/:version?/:page?", map[string]string{"version": "v1", "page": "intro"}},
+		{"/assets", "/assets/*filepath", map[string]string{"filepath": ""}},
+		{"/assets/js/a.js", "/assets/*filepath", map[string]string{"filepath": "js/a.js"}},
+		{"/files/a/b/raw", "/files/*path/raw", map[string]string{"path": "a/b"}},
+		{"/files/raw", "/files/*path", map[string]string{"path": "raw"}},
+		{"/files/a/b", "/files/*path", map[string]string{"path": "a/b"}},
+		{"/files/new", "/files/new", map[string]string{}},
+	}
+	for _, tt := range tests {
+		n, ps := r.getRoute("GET", tt.path)
+		if n == nil || n.pattern != tt.pattern {
+			t.Fatalf("%s should match %s, got %+v", tt.path, tt.pattern, n)
+		}
+		if !reflect.DeepEqual(ps, tt.params) {
+			t.Fatalf("%s: unexpected params %v", tt.path, ps)
+		}
+	}
+	if n, _ := r.getRoute("GET", "/docs/v1/intro/more"); n != nil {
+		t.Fatalf("/docs/v1/intro/more should not match, got %s", n.pattern)
+	}
+}
+
+func TestAddRouteConflict(t *testing.T) {
+	tests := [][]string{
+		{"/hello/:name", "/hello/:id"},
+		{"/assets/*filepath", "/assets/*path"},
+		{"/docs/:version?", "/docs"},
+		{"/hello/:name", "/hello/:name"},
+		{"/docs/:version?/intro"},
+	}
+	for _, patterns := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%v should panic", patterns)
+				}
+			}()
+			r := newRouter()
+			for _, pattern := range patterns {
+				r.addRoute("GET", pattern, nil)
+			}
+		}()
+	}
+}
@@ -0,0 +1,108 @@
+package gee
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// H2CHandler returns e wrapped to accept HTTP/2 cleartext connections, both with prior knowledge
+// and by Upgrade: h2c, HTTP/1.x requests are served by e unchanged
+func (e *Engine) H2CHandler() http.Handler {
+	return h2c.NewHandler(e, &http2.Server{})
+}
+
+// RunTLS 启动 HTTPS 服务，客户端通过 ALPN 协商使用 HTTP/2
+func (e *Engine) RunTLS(addr, certFile, keyFile string) error {
+	return http.ListenAndServeTLS(addr, certFile, keyFile, e)
+}
+
+// Push initiates an HTTP/2 server push of target, it returns http.ErrNotSupported if the
+// connection does not support push, eg. HTTP/1.x or the client disabled push
+func (c *Context) Push(target string, opts *http.PushOptions) error {
+	w := c.Writer
+	for {
+		if p, ok := w.(http.Pusher); ok {
+			return p.Push(target, opts)
+		}
+		// 中间件包装的 ResponseWriter 可能没有实现 http.Pusher，逐层查找
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return http.ErrNotSupported
+		}
+		w = u.Unwrap()
+	}
+}
+
+// Flush 将缓冲的响应立即发送给客户端，HTTP/2 下会发送 DATA 帧
+func (c *Context) Flush() {
+	if f, ok := c.Writer.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Stream 循环调用 step 写出响应，每次调用后 Flush，step 返回 false 时结束。
+// 客户端断开连接(HTTP/2 下为流被取消)时提前结束并返回 true。
+// HTTP/2 流控窗口用完时写操作会阻塞，直到客户端读取数据或者断开连接
+func (c *Context) Stream(step func(w io.Writer) bool) bool {
+	done := c.Req.Context().Done()
+	for {
+		select {
+		case <-done:
+			return true
+		default:
+		}
+		keepOpen := step(c.Writer)
+		c.Flush()
+		if !keepOpen {
+			return false
+		}
+	}
+}
+
+// SSEvent writes a server-sent event and flushes it, data other than string and []byte is encoded as JSON.
+// The event stream headers are set by the first call.
+func (c *Context) SSEvent(event string, data interface{}) error {
+	header := c.Writer.Header()
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "text/event-stream")
+		header.Set("Cache-Control", "no-cache")
+		header.Set("X-Accel-Buffering", "no")
+		// HTTP/2 禁止 Connection 等逐跳头部
+		if c.Req.ProtoMajor == 1 {
+			header.Set("Connection", "keep-alive")
+		}
+	}
+
+	var payload string
+	switch v := data.(type) {
+	case string:
+		payload = v
+	case []byte:
+		payload = string(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		payload = string(b)
+	}
+	var sb strings.Builder
+	if event != "" {
+		fmt.Fprintf(&sb, "event: %s\n", event)
+	}
+	for _, line := range strings.Split(payload, "\n") {
+		fmt.Fprintf(&sb, "data: %s\n", line)
+	}
+	sb.WriteString("\n")
+	if _, err := io.WriteString(c.Writer, sb.String()); err != nil {
+		return err
+	}
+	c.Flush()
+	return nil
+}
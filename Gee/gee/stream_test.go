@@ -0,0 +1,69 @@
+package gee
+
+import (
+	"bufio"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/http2"
+)
+
+func TestH2CStream(t *testing.T) {
+	r := New()
+	r.GET("/events", func(c *Context) {
+		if err := c.Push("/app.js", nil); err != http.ErrNotSupported {
+			t.Errorf("push should not be supported when the client disables it, got %v", err)
+		}
+		n := 0
+		c.Stream(func(w io.Writer) bool {
+			n++
+			_ = c.SSEvent("tick", H{"n": n})
+			return n < 3
+		})
+	})
+	srv := httptest.NewServer(r.H2CHandler())
+	defer srv.Close()
+
+	// prior knowledge: 直接使用 HTTP/2 明文连接
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	resp, err := client.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.ProtoMajor != 2 || resp.Header.Get("Content-Type") != "text/event-stream" || resp.Header.Get("Connection") != "" {
+		t.Fatalf("unexpected response %s %v", resp.Proto, resp.Header)
+	}
+	var events []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "data: ") {
+			events = append(events, line)
+		}
+	}
+	if len(events) != 3 || events[2] != `data: {"n":3}` {
+		t.Fatalf("unexpected events %v", events)
+	}
+}
+
+func TestSSEvent(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := newContext(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	_ = c.SSEvent("message", "a\nb")
+	if w.Body.String() != "event: message\ndata: a\ndata: b\n\n" || !w.Flushed || w.Header().Get("Connection") != "keep-alive" {
+		t.Fatalf("unexpected event %q", w.Body.String())
+	}
+	if err := c.Push("/app.js", nil); err != http.ErrNotSupported {
+		t.Fatalf("push should not be supported over HTTP/1.1, got %v", err)
+	}
+}
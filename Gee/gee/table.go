@@ -0,0 +1,119 @@
+package gee
+
+import (
+	"log"
+	"sync/atomic"
+)
+
+// 路由表，包含 router、所有 group 和 host。Engine 通过 atomic.Value 保存当前使用的路由表，
+// 可以在运行时另外构建一个完整的路由表，再通过 Engine.Swap 原子替换:
+// 正在处理的请求继续使用旧的路由表，之后的请求使用新的路由表，不会看到构建了一半的 trie
+// 通过 Engine 注册的路由、分组和 host 作用于当前路由表，Engine.Use 注册的中间件对所有路由表生效。
+// 路由表被 Swap 或者开始处理请求之后不能再修改，处理请求时读取路由表不需要加锁
+
+// Table is a set of routes, groups and hosts that can be swapped into a running Engine
+type Table struct {
+	*RouterGroup
+	router *router
+	groups []*RouterGroup
+	routes []*RouteInfo // routes 按注册顺序记录所有路由，用于生成文档
+	hosts  []*host      // hosts 基于 Host 的路由，按优先级排序，见 Table.Host
+	live   int32        // live 为 1 时路由表可能正在处理请求，不能再修改
+}
+
+func newTable(e *Engine) *Table {
+	t := &Table{router: newRouter()}
+	t.RouterGroup = &RouterGroup{engine: e, table: t}
+	t.groups = []*RouterGroup{t.RouterGroup}
+	return t
+}
+
+// NewTable creates an empty route table of e, routes are registered on it off to the side and it takes effect after Swap.
+// Middlewares added by Engine.Use apply to it as well
+func (e *Engine) NewTable() *Table {
+	return newTable(e)
+}
+
+// Swap atomically replaces the route table used to serve requests and returns the previous one,
+// t can not be modified afterwards
+func (e *Engine) Swap(t *Table) *Table {
+	if t.engine != e {
+		panic("gee: route table belongs to another engine")
+	}
+	e.mu.Lock()
+	atomic.StoreInt32(&t.live, 1)
+	old := e.table()
+	e.current.Store(t)
+	e.mu.Unlock()
+	log.Printf("Swap route table: %d routes", len(t.routes))
+	return old
+}
+
+// setLive 标记路由表开始处理请求，之后的修改会 panic
+func (t *Table) setLive() {
+	if atomic.LoadInt32(&t.live) == 0 {
+		t.engine.mu.Lock()
+		atomic.StoreInt32(&t.live, 1)
+		t.engine.mu.Unlock()
+	}
+}
+
+// modify 在 Engine.mu 的保护下修改路由表，与 setLive 互斥，保证处理请求时不会读到修改了一半的路由表
+func (t *Table) modify(fn func()) {
+	t.engine.mu.Lock()
+	defer t.engine.mu.Unlock()
+	if atomic.LoadInt32(&t.live) == 1 {
+		panic("gee: route table is live, register routes on a new table from Engine.NewTable and Swap it in")
+	}
+	fn()
+}
+
+// table 返回当前使用的路由表
+func (e *Engine) table() *Table {
+	return e.current.Load().(*Table)
+}
+
+// Routes returns all routes of the table in registration order
+func (t *Table) Routes() []*RouteInfo {
+	return t.routes
+}
+
+// Route returns the route registered on the table itself (not on a host) with method and pattern, or nil
+func (t *Table) Route(method, pattern string) *RouteInfo {
+	for _, route := range t.routes {
+		if route.Host == "" && route.Method == method && route.Pattern == pattern {
+			return route
+		}
+	}
+	return nil
+}
+
+func (t *Table) addRoute(h *host, method, pattern string, handler HandlerFunc) *RouteInfo {
+	r := t.router
+	route := &RouteInfo{Method: method, Pattern: pattern}
+	if h != nil {
+		r = h.router
+		route.Host = h.pattern
+	}
+	t.modify(func() {
+		r.addRoute(method, pattern, handler)
+		r.routes[method+"-"+pattern] = route
+		t.routes = append(t.routes, route)
+	})
+	return route
+}
+
+// Enable 重新启用被 Disable 的路由，可以在处理请求的同时调用
+func (r *RouteInfo) Enable() {
+	atomic.StoreInt32(&r.disabled, 0)
+}
+
+// Disable 禁用路由，之后匹配到该路由的请求返回 404，可以在处理请求的同时调用
+func (r *RouteInfo) Disable() {
+	atomic.StoreInt32(&r.disabled, 1)
+}
+
+// Enabled reports whether the route is enabled
+func (r *RouteInfo) Enabled() bool {
+	return atomic.LoadInt32(&r.disabled) == 0
+}
@@ -0,0 +1,155 @@
+package gee
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestSwap(t *testing.T) {
+	r := New()
+	r.Use(func(c *Context) {
+		c.SetHeader("X-Global", "1")
+		c.Next()
+	})
+	build := func(version int) *Table {
+		table := r.NewTable()
+		// 路由较多，构建期间如果被使用会出现 404
+		for i := 0; i < 100; i++ {
+			table.GET(fmt.Sprintf("/r%d/:id", i), func(c *Context) {
+				c.String(http.StatusOK, "%d", version)
+			})
+		}
+		return table
+	}
+	r.Swap(build(0))
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	errs := make(chan string, 8)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/r%d/x", 99-i), nil))
+				if w.Code != http.StatusOK || w.Header().Get("X-Global") != "1" {
+					errs <- fmt.Sprintf("unexpected response %d %q", w.Code, w.Body.String())
+					return
+				}
+			}
+		}(i)
+	}
+	// 处理请求的同时修改全局中间件和读取路由
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			r.Use(func(c *Context) { c.Next() })
+			_ = r.Routes()
+		}
+	}()
+	for v := 1; v <= 20; v++ {
+		r.Swap(build(v))
+	}
+	close(stop)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/r0/x", nil))
+	if w.Body.String() != "20" || len(r.Routes()) != 100 {
+		t.Fatalf("the last table should be used, got %q", w.Body.String())
+	}
+}
+
+func TestRegisterOnLiveTable(t *testing.T) {
+	r := New()
+	r.GET("/before", func(c *Context) {
+		c.String(http.StatusOK, "before")
+	})
+	v1 := r.Group("/v1")
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+	if w := serve("/before"); w.Body.String() != "before" {
+		t.Fatalf("unexpected response %q", w.Body.String())
+	}
+
+	expectPanic := func(name string, fn func()) {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("%s: modifying a live table should panic", name)
+			}
+		}()
+		fn()
+	}
+	noop := func(c *Context) {}
+	expectPanic("Engine.GET", func() { r.GET("/after", noop) })
+	expectPanic("RouterGroup.GET", func() { v1.GET("/after", noop) })
+	expectPanic("RouterGroup.Use", func() { v1.Use(noop) })
+	expectPanic("Engine.Group", func() { r.Group("/v2") })
+
+	table := r.NewTable()
+	table.GET("/after", func(c *Context) {
+		c.String(http.StatusOK, "after")
+	})
+	// NewTable 之后通过 Engine 添加的中间件同样作用于新的路由表
+	r.Use(func(c *Context) {
+		c.SetHeader("X-Global", "1")
+		c.Next()
+	})
+	old := r.Swap(table)
+	if w := serve("/after"); w.Body.String() != "after" || w.Header().Get("X-Global") != "1" {
+		t.Fatalf("swapped table should be served, got %d %q", w.Code, w.Body.String())
+	}
+	expectPanic("swapped table", func() { table.GET("/later", noop) })
+	if len(old.Routes()) != 1 || len(r.Routes()) != 1 || r.Route("GET", "/after") == nil {
+		t.Fatal("Engine should resolve routes from the current table")
+	}
+}
+
+func TestRouteDisable(t *testing.T) {
+	r := New()
+	r.GET("/hello/:name", func(c *Context) {
+		c.String(http.StatusOK, "hello %s", c.Param("name"))
+	})
+	r.Host("api.example.com").GET("/beta", func(c *Context) {
+		c.String(http.StatusOK, "beta")
+	}).Disable()
+
+	get := func(host, path string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Host = host
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := get("example.com", "/hello/geektutu"); code != http.StatusOK {
+		t.Fatalf("enabled route should be served, got %d", code)
+	}
+	r.Route("GET", "/hello/:name").Disable()
+	if code := get("example.com", "/hello/geektutu"); code != http.StatusNotFound {
+		t.Fatalf("disabled route should return 404, got %d", code)
+	}
+	if code := get("api.example.com", "/beta"); code != http.StatusNotFound {
+		t.Fatalf("disabled host route should return 404, got %d", code)
+	}
+	r.Routes()[1].Enable()
+	if code := get("api.example.com", "/beta"); code != http.StatusOK {
+		t.Fatalf("enabled host route should be served, got %d", code)
+	}
+}
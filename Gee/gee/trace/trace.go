@@ -0,0 +1,57 @@
+// Package trace 实现 gee 的链路追踪中间件
+// 解析请求头中的 W3C traceparent，为每个请求创建 span 并放入 c.Req.Context()，
+// handler 使用该 context 调用 GeeRPC、GeeORM 等下游即可串联整条调用链
+package trace
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/MarkRepo/Gee/Gee/gee"
+	"github.com/MarkRepo/Gee/GeeTrace/tracing"
+)
+
+// TraceIDHeader 响应头中返回的 trace id，便于根据响应查找日志
+const TraceIDHeader = "X-Trace-Id"
+
+// New returns the tracing middleware, tracing.DefaultTracer is used if tracer is not provided
+func New(tracer ...*tracing.Tracer) gee.HandlerFunc {
+	t := tracing.DefaultTracer
+	if len(tracer) > 0 && tracer[0] != nil {
+		t = tracer[0]
+	}
+	return func(c *gee.Context) {
+		ctx := c.Req.Context()
+		if sc, err := tracing.ParseTraceparent(c.Req.Header.Get(tracing.TraceparentHeader)); err == nil {
+			ctx = tracing.ContextWithRemoteSpanContext(ctx, sc)
+		}
+		route := c.Pattern
+		if route == "" {
+			route = c.Path
+		}
+		ctx, span := t.Start(ctx, c.Method+" "+route)
+		defer span.End()
+		span.SetAttribute("http.method", c.Method)
+		span.SetAttribute("http.route", c.Pattern)
+		span.SetAttribute("http.target", c.Req.URL.RequestURI())
+		c.Req = c.Req.WithContext(ctx)
+		c.SetHeader(TraceIDHeader, span.SpanContext().TraceID.String())
+
+		c.Next()
+
+		code := c.StatusCode
+		if code == 0 {
+			code = http.StatusOK
+		}
+		span.SetAttribute("http.status_code", strconv.Itoa(code))
+		if code >= http.StatusInternalServerError {
+			span.SetError(errStatus(code))
+		}
+	}
+}
+
+type errStatus int
+
+func (e errStatus) Error() string {
+	return "http status " + strconv.Itoa(int(e)) + " " + http.StatusText(int(e))
+}
@@ -0,0 +1,38 @@
+package trace
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MarkRepo/Gee/Gee/gee"
+	"github.com/MarkRepo/Gee/GeeTrace/tracing"
+)
+
+func TestTrace(t *testing.T) {
+	exporter := &tracing.InMemoryExporter{}
+	r := gee.New()
+	r.Use(New(tracing.NewTracer(exporter)))
+	var inner tracing.SpanContext
+	r.GET("/user/:id", func(c *gee.Context) {
+		inner, _ = tracing.SpanContextFromContext(c.Req.Context())
+		c.String(http.StatusInternalServerError, "boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/user/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	spans := exporter.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("expect 1 span, got %d", len(spans))
+	}
+	s := spans[0]
+	if s.Name != "GET /user/:id" || s.SpanContext() != inner || s.Err() == nil {
+		t.Fatalf("unexpected span %s %+v, err %v", s.Name, s.SpanContext(), s.Err())
+	}
+	if w.Header().Get(TraceIDHeader) != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("unexpected trace id header %q", w.Header().Get(TraceIDHeader))
+	}
+}
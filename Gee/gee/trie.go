@@ -0,0 +1,164 @@
+package gee
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 所谓动态路由，即一条路由规则可以匹配某一类型而非某一条固定的路由.
+// 实现动态路由最常用的数据结构，被称为前缀树(Trie树),每一个节点的所有的子节点都拥有相同的前缀
+// 我们实现的动态路由具有以下功能：
+// 1. 参数匹配:。例如 /p/:lang/doc，可以匹配 /p/c/doc 和 /p/go/doc。
+// 2. 通配*。例如 /static/*filepath，可以匹配/static/fav.ico，
+// 也可以匹配/static/js/jQuery.js，这种模式常用于静态服务器，能够递归地匹配子路径，也可以匹配 /static，此时 filepath 为空
+// 3. 可选参数:?。例如 /docs/:version?，可以匹配 /docs 和 /docs/v1，注册时展开为两条路由
+// 4. 带静态后缀的通配。例如 /files/*path/raw，可以匹配 /files/a/b/raw，path 至少匹配一段
+// 匹配时静态节点优先于参数节点，参数节点优先于通配节点
+
+type node struct {
+	pattern  string   // 待匹配路由，即注册时的原始路由
+	parts    []string // parts 展开可选参数后，该节点对应的路由片段，用于解析参数
+	part     string   // 路由中的一部分
+	children []*node  // 所有子节点
+	isWild   bool     // 是否通配，part含有 : 或 * 时为 true
+}
+
+func (n *node) isCatchAll() bool {
+	return strings.HasPrefix(n.part, "*")
+}
+
+// 与 part 完全相同的子节点，用于插入
+func (n *node) matchChild(part string) *node {
+	for _, child := range n.children {
+		if child.part == part {
+			return child
+		}
+	}
+	return nil
+}
+
+// 所有匹配成功的节点，用于查询，按 静态、参数、通配 的顺序返回
+func (n *node) matchChildren(part string) []*node {
+	nodes := make([]*node, 0)
+	for _, child := range n.children {
+		if child.part == part {
+			nodes = append(nodes, child)
+		}
+	}
+	for _, child := range n.children {
+		if child.isWild && !child.isCatchAll() {
+			nodes = append(nodes, child)
+		}
+	}
+	for _, child := range n.children {
+		if child.isCatchAll() {
+			nodes = append(nodes, child)
+		}
+	}
+	return nodes
+}
+
+// 递归查找每一层的节点，如果没有匹配到当前part的节点，则新建一个，有一点需要注意，/p/:lang/doc只有在第三层节点，即doc节点，
+// pattern才会设置为/p/:lang/doc。p和:lang节点的pattern属性皆为空。因此，当匹配结束时，
+// 我们可以使用n.pattern == ""来判断路由规则是否匹配成功
+// 同一位置的参数(或通配)名称不同、或者同一条路由重复注册时会 panic
+func (n *node) insert(pattern string, parts []string, height int) {
+	if len(parts) == height {
+		if n.pattern != "" {
+			panic(fmt.Sprintf("gee: route %s conflicts with %s", pattern, n.pattern))
+		}
+		n.pattern = pattern
+		n.parts = parts
+		return
+	}
+
+	part := parts[height]
+	child := n.matchChild(part)
+	if child == nil {
+		isWild := part[0] == '*' || part[0] == ':'
+		if isWild {
+			for _, c := range n.children {
+				if c.isWild && c.part[0] == part[0] {
+					panic(fmt.Sprintf("gee: %s in route %s conflicts with existing %s", part, pattern, c.part))
+				}
+			}
+		}
+		child = &node{
+			part:   part,
+			isWild: isWild,
+		}
+		n.children = append(n.children, child)
+	}
+
+	child.insert(pattern, parts, height+1)
+}
+
+// 查询功能，同样也是递归查询每一层的节点，退出规则是: 匹配到了*(交给 searchCatchAll)，匹配失败，或者匹配到了第len(parts)层节点
+func (n *node) search(parts []string, height int) *node {
+	if len(parts) == height {
+		if n.pattern != "" {
+			return n
+		}
+		// 通配可以匹配空路径
+		for _, child := range n.children {
+			if child.isCatchAll() && child.pattern != "" {
+				return child
+			}
+		}
+		return nil
+	}
+
+	part := parts[height]
+	children := n.matchChildren(part)
+	for _, child := range children {
+		var result *node
+		if child.isCatchAll() {
+			result = child.searchCatchAll(parts, height)
+		} else {
+			result = child.search(parts, height+1)
+		}
+		if result != nil {
+			return result
+		}
+	}
+
+	return nil
+}
+
+// searchCatchAll 通配节点匹配 parts[start:]，优先匹配带静态后缀的路由
+func (n *node) searchCatchAll(parts []string, start int) *node {
+	if result := n.searchSuffix(parts, start, nil); result != nil {
+		return result
+	}
+	if n.pattern != "" {
+		return n
+	}
+	return nil
+}
+
+// searchSuffix 在通配节点的静态子树中查找与 parts 末尾相同的后缀，通配部分至少保留一段
+func (n *node) searchSuffix(parts []string, start int, suffix []string) *node {
+	for _, child := range n.children {
+		s := append(suffix[:len(suffix):len(suffix)], child.part)
+		end := len(parts) - len(s)
+		if child.pattern != "" && end > start && equalParts(parts[end:], s) {
+			return child
+		}
+		if result := child.searchSuffix(parts, start, s); result != nil {
+			return result
+		}
+	}
+	return nil
+}
+
+func equalParts(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
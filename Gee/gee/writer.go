@@ -0,0 +1,35 @@
+package gee
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// ResponseWrapper 包装 http.ResponseWriter，供中间件嵌入后只覆盖需要的方法，
+// 同时保留被包装 ResponseWriter 的 Flusher 和 Hijacker 能力
+type ResponseWrapper struct {
+	http.ResponseWriter
+}
+
+// Flush 被包装的 ResponseWriter 没有实现 http.Flusher 时什么都不做
+func (w ResponseWrapper) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack 用于 GeeRPC 的 CONNECT、WebSocket 等接管连接的场景
+func (w ResponseWrapper) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("gee: response writer does not implement http.Hijacker")
+	}
+	return h.Hijack()
+}
+
+// Unwrap 返回被包装的 http.ResponseWriter，Context.Push 通过它逐层查找 http.Pusher
+func (w ResponseWrapper) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
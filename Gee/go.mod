@@ -1,3 +1,22 @@
 module github.com/MarkRepo/Gee/Gee
 
-go 1.16
+go 1.17
+
+require (
+	github.com/MarkRepo/Gee/GeeCache v0.0.0
+	github.com/MarkRepo/Gee/GeeTrace v0.0.0
+	golang.org/x/net v0.17.0
+)
+
+require (
+	github.com/MarkRepo/Gee/GeeRPC v0.0.0 // indirect
+	github.com/golang/protobuf v1.5.2 // indirect
+	golang.org/x/text v0.13.0 // indirect
+	google.golang.org/protobuf v1.26.0 // indirect
+)
+
+replace (
+	github.com/MarkRepo/Gee/GeeCache => ../GeeCache
+	github.com/MarkRepo/Gee/GeeRPC => ../GeeRPC
+	github.com/MarkRepo/Gee/GeeTrace => ../GeeTrace
+)
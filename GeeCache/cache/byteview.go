@@ -1,8 +1,11 @@
 package cache
 
+import "time"
+
 // ByteView holds an immutable view of bytes
 type ByteView struct {
 	b []byte
+	e time.Time // e 过期时间，零值表示永不过期
 }
 
 // Len returns the view's length
@@ -10,6 +13,33 @@ func (v ByteView) Len() int {
 	return len(v.b)
 }
 
+// Expire returns the expiration time of the view, the zero time means it never expires
+func (v ByteView) Expire() time.Time {
+	return v.e
+}
+
+// TTL returns the remaining time to live of the view, 0 means it never expires
+func (v ByteView) TTL() time.Duration {
+	if v.e.IsZero() {
+		return 0
+	}
+	if ttl := time.Until(v.e); ttl > 0 {
+		return ttl
+	}
+	// 已经过期，返回最小的正数表示立即过期，与永不过期区分
+	return time.Nanosecond
+}
+
+// ttlMillis 返回发送给其他节点的剩余有效期，单位毫秒，0 表示永不过期。
+// 不足 1 毫秒的向上取整，避免即将过期的数据被远端节点当作永不过期
+func (v ByteView) ttlMillis() int64 {
+	ttl := v.TTL()
+	if ttl <= 0 {
+		return 0
+	}
+	return int64((ttl + time.Millisecond - 1) / time.Millisecond)
+}
+
 // ByteSlice returns a copy of the data as a byte slice
 func (v ByteView) ByteSlice() []byte {
 	return cloneBytes(v.b)
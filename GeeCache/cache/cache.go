@@ -1,35 +1,246 @@
 package cache
 
 import (
+	"runtime"
 	"sync"
+	"time"
 
 	"github.com/MarkRepo/Gee/GeeCache/cache/lru"
+	"github.com/MarkRepo/Gee/GeeCache/cache/policy"
 )
 
-// cache 加锁封装lru接口、延迟初始化
+// EvictionPolicy 缓存淘汰策略，cache 加锁后调用，实现不需要并发安全
+type EvictionPolicy interface {
+	Get(key string) (lru.Value, bool)
+	PutWithExpire(key string, value lru.Value, expire time.Time)
+	Remove(key string) bool
+	RemovePrefix(prefix string) int
+	RemoveExpired() int
+	Len() int
+	Bytes() int64
+}
+
+// NewPolicyFunc 创建容量为 maxBytes 的淘汰策略，maxBytes 为 0 表示不限制，元素被删除时调用 onEvicted
+type NewPolicyFunc func(maxBytes int64, onEvicted lru.OnEvictedFunc) EvictionPolicy
+
+var (
+	LRU NewPolicyFunc = func(maxBytes int64, onEvicted lru.OnEvictedFunc) EvictionPolicy {
+		return lru.New(maxBytes, onEvicted)
+	}
+	LFU NewPolicyFunc = func(maxBytes int64, onEvicted lru.OnEvictedFunc) EvictionPolicy {
+		return policy.NewLFU(maxBytes, onEvicted)
+	}
+	ARC NewPolicyFunc = func(maxBytes int64, onEvicted lru.OnEvictedFunc) EvictionPolicy {
+		return policy.NewARC(maxBytes, onEvicted)
+	}
+	// TwoQueue 2Q
+	TwoQueue NewPolicyFunc = func(maxBytes int64, onEvicted lru.OnEvictedFunc) EvictionPolicy {
+		return policy.New2Q(maxBytes, onEvicted)
+	}
+	// TinyLFU W-TinyLFU
+	TinyLFU NewPolicyFunc = func(maxBytes int64, onEvicted lru.OnEvictedFunc) EvictionPolicy {
+		return policy.NewTinyLFU(maxBytes, onEvicted)
+	}
+)
+
+// cache 按 key 的哈希值分成多个独立加锁的 shard，每个 shard 分到 cacheBytes 的一部分，
+// 避免所有读写竞争同一把锁(淘汰策略的 Get 也会修改数据，只能使用互斥锁)
+// 有过期时间的 value 在 Get 时检查是否过期，另外由后台 janitor 每隔 cleanupInterval 清理一次
 type cache struct {
+	shards          []*shard
+	cleanupInterval time.Duration
+	janitor         sync.Once
+	closeOnce       sync.Once
+	done            chan struct{} // done 关闭后 janitor 退出
+}
+
+// CacheStats 缓存的统计信息
+type CacheStats struct {
+	Bytes     int64 `json:"bytes"`
+	Items     int64 `json:"items"`
+	Gets      int64 `json:"gets"`
+	Hits      int64 `json:"hits"`
+	Evictions int64 `json:"evictions"` // Evictions 因为容量不足被淘汰的元素个数，不包括主动删除和过期
+}
+
+// minShardBytes 默认 shard 数时每个 shard 至少分到的容量，避免较大的 entry 超过 shard 的容量无法缓存
+const minShardBytes = 1 << 20
+
+// newCache 创建 n 个 shard 的 cache，n 会向上取整为 2 的幂。
+// n <= 0 时使用默认值：不小于 GOMAXPROCS 的 2 的幂，再减半直到每个 shard 至少有 minShardBytes
+func newCache(cacheBytes int64, n int, cleanupInterval time.Duration, newPolicy NewPolicyFunc) *cache {
+	defaulted := n <= 0
+	if defaulted {
+		n = runtime.GOMAXPROCS(0)
+	}
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+	for defaulted && cacheBytes > 0 && size > 1 && cacheBytes/int64(size) < minShardBytes {
+		size >>= 1
+	}
+	if newPolicy == nil {
+		newPolicy = LRU
+	}
+	c := &cache{shards: make([]*shard, size), cleanupInterval: cleanupInterval, done: make(chan struct{})}
+	for i := range c.shards {
+		// 不限制容量时 cacheBytes 为 0，每个 shard 也不限制
+		shardBytes := cacheBytes / int64(size)
+		if cacheBytes > 0 && shardBytes == 0 {
+			shardBytes = 1
+		}
+		c.shards[i] = &shard{cacheBytes: shardBytes, newPolicy: newPolicy}
+	}
+	return c
+}
+
+func (c *cache) shard(key string) *shard {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+	// FNV-1a
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+	return c.shards[h&uint32(len(c.shards)-1)]
+}
+
+func (c *cache) get(key string) (value ByteView, ok bool) {
+	return c.shard(key).get(key)
+}
+
+func (c *cache) put(key string, value ByteView) {
+	c.shard(key).put(key, value)
+	if !value.Expire().IsZero() && c.cleanupInterval > 0 {
+		c.janitor.Do(func() {
+			go c.runJanitor()
+		})
+	}
+}
+
+func (c *cache) remove(key string) {
+	c.shard(key).remove(key)
+}
+
+func (c *cache) removePrefix(prefix string) {
+	for _, s := range c.shards {
+		s.removePrefix(prefix)
+	}
+}
+
+func (c *cache) stats() CacheStats {
+	var stats CacheStats
+	for _, s := range c.shards {
+		st := s.stats()
+		stats.Bytes += st.Bytes
+		stats.Items += st.Items
+		stats.Gets += st.Gets
+		stats.Hits += st.Hits
+		stats.Evictions += st.Evictions
+	}
+	return stats
+}
+
+// close 停止 janitor
+func (c *cache) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// runJanitor 定期删除过期的 value，避免不再访问的 key 一直占用内存
+func (c *cache) runJanitor() {
+	ticker := time.NewTicker(c.cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+		for _, s := range c.shards {
+			s.removeExpired()
+		}
+	}
+}
+
+// shard 加锁封装淘汰策略接口、延迟初始化
+type shard struct {
 	mu         sync.Mutex
-	lru        *lru.Cache
+	policy     EvictionPolicy
+	newPolicy  NewPolicyFunc
 	cacheBytes int64
+	gets, hits int64
+	evictions  int64
+	putting    bool // putting 为 true 时 onEvicted 是由于容量不足淘汰
 }
 
-func (c *cache) get(key string) (value ByteView, ok bool) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	if c.lru == nil {
+func (s *shard) get(key string) (value ByteView, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gets++
+	if s.policy == nil {
 		return
 	}
-	if v, ok := c.lru.Get(key); ok {
+	if v, ok := s.policy.Get(key); ok {
+		s.hits++
 		return v.(ByteView), ok
 	}
 	return
 }
 
-func (c *cache) put(key string, value ByteView) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	if c.lru == nil {
-		c.lru = lru.New(c.cacheBytes, nil)
+func (s *shard) stats() CacheStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := CacheStats{Gets: s.gets, Hits: s.hits, Evictions: s.evictions}
+	if s.policy != nil {
+		stats.Bytes = s.policy.Bytes()
+		stats.Items = int64(s.policy.Len())
+	}
+	return stats
+}
+
+func (s *shard) put(key string, value ByteView) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.policy == nil {
+		s.policy = s.newPolicy(s.cacheBytes, s.onEvicted)
+	}
+	s.putting = true
+	s.policy.PutWithExpire(key, value, value.Expire())
+	s.putting = false
+}
+
+// onEvicted 已经持有 s.mu
+func (s *shard) onEvicted(key string, value lru.Value) {
+	if s.putting {
+		s.evictions++
+	}
+}
+
+func (s *shard) remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.policy != nil {
+		s.policy.Remove(key)
+	}
+}
+
+func (s *shard) removePrefix(prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.policy != nil {
+		s.policy.RemovePrefix(prefix)
+	}
+}
+
+func (s *shard) removeExpired() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.policy != nil {
+		s.policy.RemoveExpired()
 	}
-	c.lru.Put(key, value)
 }
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestShardedCache(t *testing.T) {
+	c := newCache(8*100, 5, 0, nil)
+	if len(c.shards) != 8 || c.shards[0].cacheBytes != 100 {
+		t.Fatalf("expect 8 shards of 100 bytes, got %d shards", len(c.shards))
+	}
+	for i := 0; i < 100; i++ {
+		c.put("key"+strconv.Itoa(i), ByteView{b: []byte("value")})
+	}
+	for _, s := range c.shards {
+		if st := s.stats(); st.Bytes > 100 {
+			t.Fatalf("shard exceeds its byte budget: %d", st.Bytes)
+		}
+	}
+	c.removePrefix("key")
+	if _, ok := c.get("key99"); ok || c.stats().Items != 0 || c.stats().Gets != 1 {
+		t.Fatalf("unexpected stats after removePrefix %+v", c.stats())
+	}
+}
+
+func TestDefaultShards(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(128))
+	for _, tt := range []struct {
+		cacheBytes int64
+		shards     int
+	}{
+		{0, 128},
+		{2 << 10, 1},
+		{8 * minShardBytes, 8},
+		{1 << 30, 128},
+	} {
+		if n := len(newCache(tt.cacheBytes, 0, 0, nil).shards); n != tt.shards {
+			t.Fatalf("cacheBytes %d: expect %d shards, got %d", tt.cacheBytes, tt.shards, n)
+		}
+	}
+}
+
+// BenchmarkCacheGet 比较不同 shard 数在并发读取时的吞吐量，例如
+//
+//	go test -run ^$ -bench CacheGet -cpu 1,4,8 ./cache
+func BenchmarkCacheGet(b *testing.B) {
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+	for _, n := range []int{1, 64} {
+		c := newCache(0, n, 0, nil)
+		for _, key := range keys {
+			c.put(key, ByteView{b: []byte(key)})
+		}
+		b.Run(fmt.Sprintf("shards=%d", len(c.shards)), func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					c.get(keys[i&(len(keys)-1)])
+					i++
+				}
+			})
+		})
+	}
+}
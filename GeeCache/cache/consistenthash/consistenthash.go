@@ -11,9 +11,10 @@ type Hash func([]byte) uint32
 // Map constains all hashed keys
 type Map struct {
 	hash     Hash
-	replicas int            // 虚拟节点倍数
-	keys     []int          // Sorted 哈希环
-	hashMap  map[int]string // 虚拟节点到真实节点的映射
+	replicas int              // 虚拟节点倍数
+	keys     []int            // Sorted 哈希环
+	hashMap  map[int]string   // 虚拟节点到真实节点的映射
+	owners   map[int][]string // 虚拟节点哈希冲突时，按加入顺序保存所有真实节点，hashMap 中是最后加入的
 }
 
 // New creates a Map instance
@@ -22,6 +23,7 @@ func New(replicas int, fn Hash) *Map {
 		replicas: replicas,
 		hash:     fn,
 		hashMap:  make(map[int]string),
+		owners:   make(map[int][]string),
 	}
 	if m.hash == nil {
 		m.hash = crc32.ChecksumIEEE
@@ -34,20 +36,73 @@ func (m *Map) Add(keys ...string) {
 	for _, key := range keys {
 		for i := 0; i < m.replicas; i++ {
 			hash := int(m.hash([]byte(strconv.Itoa(i) + key)))
-			m.keys = append(m.keys, hash)
+			if _, ok := m.hashMap[hash]; !ok {
+				m.keys = append(m.keys, hash)
+			}
 			m.hashMap[hash] = key
+			m.owners[hash] = append(m.owners[hash], key)
 		}
 	}
 	sort.Ints(m.keys)
 }
 
+// Remove removes some keys and their replicas from the hash.
+func (m *Map) Remove(keys ...string) {
+	for _, key := range keys {
+		for i := 0; i < m.replicas; i++ {
+			hash := int(m.hash([]byte(strconv.Itoa(i) + key)))
+			// 虚拟节点哈希冲突时，交给剩下的真实节点中最后加入的那个
+			owners := m.owners[hash][:0]
+			for _, owner := range m.owners[hash] {
+				if owner != key {
+					owners = append(owners, owner)
+				}
+			}
+			if len(owners) == 0 {
+				delete(m.owners, hash)
+				delete(m.hashMap, hash)
+				continue
+			}
+			m.owners[hash] = owners
+			m.hashMap[hash] = owners[len(owners)-1]
+		}
+	}
+	m.keys = m.keys[:0]
+	for hash := range m.hashMap {
+		m.keys = append(m.keys, hash)
+	}
+	sort.Ints(m.keys)
+}
+
+// Clone returns a copy of the hash, it is used to compare the hash before and after a change.
+func (m *Map) Clone() *Map {
+	c := &Map{
+		hash:     m.hash,
+		replicas: m.replicas,
+		keys:     append([]int(nil), m.keys...),
+		hashMap:  make(map[int]string, len(m.hashMap)),
+		owners:   make(map[int][]string, len(m.owners)),
+	}
+	for hash, key := range m.hashMap {
+		c.hashMap[hash] = key
+	}
+	for hash, owners := range m.owners {
+		c.owners[hash] = append([]string(nil), owners...)
+	}
+	return c
+}
+
 // Get gets the closest item in the hash to the provided key.
 func (m *Map) Get(key string) string {
+	return m.owner(int(m.hash([]byte(key))))
+}
+
+// owner 返回哈希值 hash 所在区间的真实节点
+func (m *Map) owner(hash int) string {
 	if len(m.keys) == 0 {
 		return ""
 	}
 
-	hash := int(m.hash([]byte(key)))
 	// Binary search for appropriate replica.
 	idx := sort.Search(len(m.keys), func(i int) bool {
 		return m.keys[i] >= hash
@@ -56,3 +111,54 @@ func (m *Map) Get(key string) string {
 	// 如果 idx == len(m.keys)，说明应选择 m.keys[0]
 	return m.hashMap[m.keys[idx%len(m.keys)]]
 }
+
+// Move 表示哈希环上 (Start, End] 这段区间的 key 从 From 迁移到了 To，Start >= End 时区间跨过 0 点
+type Move struct {
+	Start, End uint32
+	From, To   string
+}
+
+// Fraction 返回区间占整个哈希环的比例
+func (mv Move) Fraction() float64 {
+	size := uint64(mv.End - mv.Start)
+	if size == 0 {
+		size = 1 << 32
+	}
+	return float64(size) / (1 << 32)
+}
+
+// Diff 比较两个哈希环，返回 owner 发生变化的区间，相邻且迁移方向相同的区间会合并
+func Diff(old, new *Map) []Move {
+	points := make([]int, 0, len(old.keys)+len(new.keys))
+	points = append(points, old.keys...)
+	points = append(points, new.keys...)
+	sort.Ints(points)
+	uniq := points[:0]
+	for i, hash := range points {
+		if i == 0 || hash != points[i-1] {
+			uniq = append(uniq, hash)
+		}
+	}
+	points = uniq
+
+	var moves []Move
+	for i, end := range points {
+		// 每个区间 (start, end] 在两个哈希环上都只属于一个节点
+		start := points[(i+len(points)-1)%len(points)]
+		from, to := old.owner(end), new.owner(end)
+		if from == to {
+			continue
+		}
+		if n := len(moves); n > 0 && moves[n-1].End == uint32(start) && moves[n-1].From == from && moves[n-1].To == to {
+			moves[n-1].End = uint32(end)
+			continue
+		}
+		moves = append(moves, Move{Start: uint32(start), End: uint32(end), From: from, To: to})
+	}
+	// 第一个区间跨过 0 点，可以与最后一个区间合并
+	if n := len(moves); n > 1 && moves[0].Start == moves[n-1].End && moves[0].From == moves[n-1].From && moves[0].To == moves[n-1].To {
+		moves[0].Start = moves[n-1].Start
+		moves = moves[:n-1]
+	}
+	return moves
+}
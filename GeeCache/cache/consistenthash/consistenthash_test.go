@@ -2,6 +2,7 @@ package consistenthash
 
 import (
 	"fmt"
+	"reflect"
 	"strconv"
 	"testing"
 )
@@ -42,3 +43,58 @@ func TestHashing(t *testing.T) {
 	}
 	fmt.Printf("success\n")
 }
+
+func TestRemoveAndDiff(t *testing.T) {
+	hash := New(3, func(key []byte) uint32 {
+		i, _ := strconv.Atoi(string(key))
+		return uint32(i)
+	})
+	// 2, 4, 6, 12, 14, 16, 22, 24, 26
+	hash.Add("6", "4", "2")
+	old := hash.Clone()
+
+	// Removes 4, 14, 24
+	hash.Remove("4")
+	if hash.Get("23") != "6" || hash.Get("13") != "6" || hash.Get("3") != "6" {
+		t.Fatal("keys of removed node should move to the next node")
+	}
+	expect := []Move{
+		{Start: 2, End: 4, From: "4", To: "6"},
+		{Start: 12, End: 14, From: "4", To: "6"},
+		{Start: 22, End: 24, From: "4", To: "6"},
+	}
+	if moves := Diff(old, hash); !reflect.DeepEqual(moves, expect) {
+		t.Fatalf("unexpected moves %v", moves)
+	}
+	if moves := Diff(hash, hash.Clone()); len(moves) != 0 {
+		t.Fatalf("same hash should not move keys, got %v", moves)
+	}
+
+	// 只有一个节点时，所有 key 都迁移到新节点
+	single := New(1, nil)
+	single.Add("a")
+	moves := Diff(New(1, nil), single)
+	if len(moves) != 1 || moves[0].From != "" || moves[0].To != "a" || moves[0].Fraction() != 1 {
+		t.Fatalf("unexpected moves %v", moves)
+	}
+}
+
+func TestRemoveCollision(t *testing.T) {
+	hash := New(3, func(key []byte) uint32 {
+		i, _ := strconv.Atoi(string(key))
+		return uint32(i)
+	})
+	// "2" 的虚拟节点 2, 12, 22，"02" 的虚拟节点 2, 102, 202，在 2 上冲突
+	hash.Add("2", "02")
+	if hash.Get("1") != "02" {
+		t.Fatal("the last added node should own the colliding point")
+	}
+	hash.Remove("02")
+	if hash.Get("1") != "2" || hash.Get("100") != "2" {
+		t.Fatal("colliding point should be kept for the remaining node")
+	}
+	hash.Remove("2")
+	if hash.Get("1") != "" {
+		t.Fatal("all points should be removed")
+	}
+}
@@ -1,9 +1,13 @@
 package cache
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"math/rand"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	pb "github.com/MarkRepo/Gee/GeeCache/cache/cachepb"
 	"github.com/MarkRepo/Gee/GeeCache/cache/singleflight"
@@ -19,13 +23,72 @@ func (f GetterFunc) Get(key string) ([]byte, error) {
 	return f(key)
 }
 
+// GetterWithTTL 在返回数据的同时返回数据的有效期，ttl <= 0 时使用 Group 的默认 TTL
+type GetterWithTTL interface {
+	Getter
+	GetWithTTL(key string) ([]byte, time.Duration, error)
+}
+
+type GetterWithTTLFunc func(key string) ([]byte, time.Duration, error)
+
+func (f GetterWithTTLFunc) Get(key string) ([]byte, error) {
+	b, _, err := f(key)
+	return b, err
+}
+
+func (f GetterWithTTLFunc) GetWithTTL(key string) ([]byte, time.Duration, error) {
+	return f(key)
+}
+
+// GetterWithContext 在 ctx 结束时可以放弃加载，同时可以像 GetterWithTTL 一样返回数据的有效期
+type GetterWithContext interface {
+	Getter
+	GetContext(ctx context.Context, key string) ([]byte, time.Duration, error)
+}
+
+type GetterWithContextFunc func(ctx context.Context, key string) ([]byte, time.Duration, error)
+
+func (f GetterWithContextFunc) Get(key string) ([]byte, error) {
+	b, _, err := f(context.Background(), key)
+	return b, err
+}
+
+func (f GetterWithContextFunc) GetContext(ctx context.Context, key string) ([]byte, time.Duration, error) {
+	return f(ctx, key)
+}
+
+// GroupOptions Group 的可选配置
+type GroupOptions struct {
+	TTL             time.Duration // TTL 本地加载的数据默认有效期，0 表示永不过期
+	CleanupInterval time.Duration // CleanupInterval 后台清理过期数据的间隔，默认 1 分钟
+	HotCacheBytes   int64         // HotCacheBytes 从 cacheBytes 中分给 hot cache 的字节数，默认 cacheBytes/8，小于 0 表示不使用 hot cache
+	HotSampleRate   int           // HotSampleRate 从远端节点获取的数据每 HotSampleRate 个中保存一个到 hot cache，默认 10
+	Policy          NewPolicyFunc // Policy main cache 和 hot cache 的淘汰策略，默认 LRU
+	Shards          int           // Shards main cache 和 hot cache 分成的 shard 数，向上取整为 2 的幂。每个 shard 只有 cacheBytes/Shards 的容量，超过的 entry 无法缓存，默认接近 GOMAXPROCS，同时保证每个 shard 至少 1MB
+}
+
+// CacheType 区分 Group 的两个缓存
+type CacheType int
+
+const (
+	// MainCache 保存本节点作为 owner 的数据
+	MainCache CacheType = iota + 1
+	// HotCache 保存从远端节点获取的热点数据，减少网络请求
+	HotCache
+)
+
 // Group 实现cache group， 分布式缓存的核心逻辑
 type Group struct {
+	stats  Stats // stats 放在第一个字段，保证 32 位平台上原子操作的 64 位对齐
 	name   string
 	getter Getter
-	c      cache
+	c      *cache // c main cache
+	hot    *cache // hot hot cache
 	peers  PeerPicker
 	loader *singleflight.Group
+	ttl    time.Duration
+
+	hotSampleRate int // hotSampleRate 为 0 时不使用 hot cache
 }
 
 var (
@@ -34,17 +97,37 @@ var (
 )
 
 // NewGroup create a new instance of Group
-func NewGroup(name string, cacheBytes int64, getter Getter) *Group {
+func NewGroup(name string, cacheBytes int64, getter Getter, opts ...GroupOptions) *Group {
 	if getter == nil {
 		panic("nil Getter")
 	}
+	var opt GroupOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.CleanupInterval == 0 {
+		opt.CleanupInterval = time.Minute
+	}
+	if opt.HotCacheBytes == 0 {
+		opt.HotCacheBytes = cacheBytes / 8
+	}
+	if opt.HotSampleRate == 0 {
+		opt.HotSampleRate = 10
+	}
+	if opt.HotCacheBytes < 0 || (cacheBytes > 0 && opt.HotCacheBytes >= cacheBytes) {
+		opt.HotCacheBytes, opt.HotSampleRate = 0, 0
+	}
 	mu.Lock()
 	defer mu.Unlock()
 	g := &Group{
 		name:   name,
 		getter: getter,
-		c:      cache{cacheBytes: cacheBytes},
+		c:      newCache(cacheBytes-opt.HotCacheBytes, opt.Shards, opt.CleanupInterval, opt.Policy),
+		hot:    newCache(opt.HotCacheBytes, opt.Shards, opt.CleanupInterval, opt.Policy),
 		loader: &singleflight.Group{},
+		ttl:    opt.TTL,
+
+		hotSampleRate: opt.HotSampleRate,
 	}
 	groups[name] = g
 	return g
@@ -59,17 +142,36 @@ func GetGroup(name string) *Group {
 	return g
 }
 
-// Get 逻辑：优先取缓存，如果没有则 load
-func (g *Group) Get(key string) (ByteView, error) {
+// Get 逻辑：优先取缓存，如果没有则 load。ctx 结束时不再等待 load，直接返回 ctx.Err()
+func (g *Group) Get(ctx context.Context, key string) (ByteView, error) {
 	if key == "" {
 		return ByteView{}, fmt.Errorf("key is required")
 	}
+	atomic.AddInt64(&g.stats.Gets, 1)
 	if v, ok := g.c.get(key); ok {
-		log.Println("[GeeCache] hit， key: %s, value: %s", key, v)
+		atomic.AddInt64(&g.stats.CacheHits, 1)
+		log.Printf("[GeeCache] hit， key: %s", key)
+		return v, nil
+	}
+	if v, ok := g.hot.get(key); ok {
+		atomic.AddInt64(&g.stats.CacheHits, 1)
+		log.Printf("[GeeCache] hot hit， key: %s", key)
 		return v, nil
 	}
 
-	return g.load(key)
+	return g.load(ctx, key)
+}
+
+// Close stops the background cleanup of the group's caches and unregisters the group,
+// GetGroup returns nil for its name afterwards
+func (g *Group) Close() {
+	g.c.close()
+	g.hot.close()
+	mu.Lock()
+	if groups[g.name] == g {
+		delete(groups, g.name)
+	}
+	mu.Unlock()
 }
 
 // RegisterPeers registers a PeerPicker for choosing remote peer
@@ -80,46 +182,90 @@ func (g *Group) RegisterPeers(peers PeerPicker) {
 	g.peers = peers
 }
 
-// load 使用 HTTPPool 的节点选择能力，选择一个远端节点获取，如果获取失败，或者选择到了自己，则使用本地获取，并放入本地缓存
-func (g *Group) load(key string) (value ByteView, err error) {
-	viewi, err := g.loader.Do(key, func() (interface{}, error) {
+// load 使用 HTTPPool 的节点选择能力，选择一个远端节点获取，如果获取失败，或者选择到了自己，则使用本地获取，并放入本地缓存。
+// 同一个 key 的并发 load 共享一次加载，某个调用方的 ctx 结束只会让它自己放弃等待
+func (g *Group) load(ctx context.Context, key string) (ByteView, error) {
+	atomic.AddInt64(&g.stats.Loads, 1)
+	viewi, err := g.loader.DoContext(ctx, key, func(ctx context.Context) (interface{}, error) {
+		// 同一个 key 的并发 load 只有一个会执行到这里
+		atomic.AddInt64(&g.stats.LoadsDeduped, 1)
 		if g.peers != nil {
 			if peer, ok := g.peers.PickPeer(key); ok {
-				if value, err = g.getFromPeer(peer, key); err == nil {
+				value, err := g.getFromPeer(ctx, peer, key)
+				if err == nil {
+					atomic.AddInt64(&g.stats.PeerLoads, 1)
 					return value, nil
 				}
+				atomic.AddInt64(&g.stats.PeerErrors, 1)
 				log.Println("[GeeCache] Failed to get from peer", err)
+				if ctx.Err() != nil {
+					// 所有调用方都已经放弃，不再从本地加载
+					return nil, ctx.Err()
+				}
 			}
 		}
 
-		return g.getLocally(key)
+		value, err := g.getLocally(ctx, key)
+		if err != nil {
+			atomic.AddInt64(&g.stats.LocalLoadErrs, 1)
+			return nil, err
+		}
+		atomic.AddInt64(&g.stats.LocalLoads, 1)
+		return value, nil
 	})
 
-	if err == nil {
-		return viewi.(ByteView), nil
+	if err != nil {
+		return ByteView{}, err
 	}
-	return
+	return viewi.(ByteView), nil
 }
 
-func (g *Group) getFromPeer(peer PeerGetter, key string) (ByteView, error) {
+func (g *Group) getFromPeer(ctx context.Context, peer PeerGetter, key string) (ByteView, error) {
 	req := &pb.Request{
 		Group: g.name,
 		Key:   key,
 	}
 	res := &pb.Response{}
-	err := peer.Get(req, res)
+	err := peer.Get(ctx, req, res)
 	if err != nil {
 		return ByteView{}, err
 	}
-	return ByteView{b: res.Value}, nil
+	value := ByteView{b: res.Value}
+	// 使用 owner 返回的剩余有效期，避免缓存远端数据的时间比 owner 更长
+	if res.Ttl > 0 {
+		value.e = time.Now().Add(time.Duration(res.Ttl) * time.Millisecond)
+	}
+	// 抽样保存到 hot cache，避免所有远端数据挤占本地缓存
+	if g.hotSampleRate > 0 && rand.Intn(g.hotSampleRate) == 0 {
+		g.hot.put(key, value)
+	}
+	return value, nil
 }
 
-func (g *Group) getLocally(key string) (ByteView, error) {
-	b, err := g.getter.Get(key)
+func (g *Group) getLocally(ctx context.Context, key string) (ByteView, error) {
+	var (
+		b   []byte
+		ttl time.Duration
+		err error
+	)
+	switch getter := g.getter.(type) {
+	case GetterWithContext:
+		b, ttl, err = getter.GetContext(ctx, key)
+	case GetterWithTTL:
+		b, ttl, err = getter.GetWithTTL(key)
+	default:
+		b, err = g.getter.Get(key)
+	}
 	if err != nil {
 		return ByteView{}, err
 	}
+	if ttl <= 0 {
+		ttl = g.ttl
+	}
 	value := ByteView{b: cloneBytes(b)}
+	if ttl > 0 {
+		value.e = time.Now().Add(ttl)
+	}
 	g.populateCache(key, value)
 	return value, nil
 }
@@ -127,3 +273,132 @@ func (g *Group) getLocally(key string) (ByteView, error) {
 func (g *Group) populateCache(key string, value ByteView) {
 	g.c.put(key, value)
 }
+
+// Set 更新 key 的值：请求交给 owner 保存新的值，其他节点删除本地的旧值
+func (g *Group) Set(ctx context.Context, key string, value []byte) error {
+	if key == "" {
+		return fmt.Errorf("key is required")
+	}
+	owner, err := g.pickUpdater(key)
+	if err != nil {
+		return err
+	}
+	if owner != nil {
+		if err := owner.Update(ctx, &pb.Request{Group: g.name, Key: key, Op: pb.Op_SET, Value: value}, &pb.Response{}); err != nil {
+			return err
+		}
+		g.removeLocally(key)
+	} else {
+		g.setLocally(key, value)
+	}
+	return g.broadcast(ctx, &pb.Request{Group: g.name, Key: key, Op: pb.Op_REMOVE}, owner)
+}
+
+// Remove 在所有节点上删除 key
+func (g *Group) Remove(ctx context.Context, key string) error {
+	if key == "" {
+		return fmt.Errorf("key is required")
+	}
+	g.removeLocally(key)
+	return g.broadcast(ctx, &pb.Request{Group: g.name, Key: key, Op: pb.Op_REMOVE}, nil)
+}
+
+// Invalidate 在所有节点上删除以 prefix 开头的 key
+func (g *Group) Invalidate(ctx context.Context, prefix string) error {
+	g.invalidateLocally(prefix)
+	return g.broadcast(ctx, &pb.Request{Group: g.name, Key: prefix, Op: pb.Op_INVALIDATE}, nil)
+}
+
+// pickUpdater 返回 key 的 owner，owner 是自己时返回 nil
+func (g *Group) pickUpdater(key string) (PeerUpdater, error) {
+	if g.peers == nil {
+		return nil, nil
+	}
+	peer, ok := g.peers.PickPeer(key)
+	if !ok {
+		return nil, nil
+	}
+	updater, ok := peer.(PeerUpdater)
+	if !ok {
+		return nil, fmt.Errorf("peer of key %s does not support updates", key)
+	}
+	return updater, nil
+}
+
+// broadcast 并发地将 req 发送给除 skip 以外的所有远端节点，返回第一个错误。
+// 每个节点的请求受 ctx 和节点自身的超时时间限制，慢节点不会拖慢其他节点
+func (g *Group) broadcast(ctx context.Context, req *pb.Request, skip PeerUpdater) error {
+	lister, ok := g.peers.(PeerLister)
+	if !ok {
+		return nil
+	}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for _, peer := range lister.ListPeers() {
+		updater, ok := peer.(PeerUpdater)
+		if !ok || updater == skip {
+			continue
+		}
+		wg.Add(1)
+		go func(updater PeerUpdater) {
+			defer wg.Done()
+			if err := updater.Update(ctx, req, &pb.Response{}); err != nil {
+				log.Println("[GeeCache] Failed to broadcast", req.Op, err)
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(updater)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// apply 处理远端节点发来的请求，只修改本地数据
+func (g *Group) apply(req *pb.Request) error {
+	switch req.Op {
+	case pb.Op_SET:
+		g.setLocally(req.Key, req.Value)
+	case pb.Op_REMOVE:
+		g.removeLocally(req.Key)
+	case pb.Op_INVALIDATE:
+		g.invalidateLocally(req.Key)
+	default:
+		return fmt.Errorf("unsupported op %v", req.Op)
+	}
+	return nil
+}
+
+func (g *Group) setLocally(key string, b []byte) {
+	value := ByteView{b: cloneBytes(b)}
+	if g.ttl > 0 {
+		value.e = time.Now().Add(g.ttl)
+	}
+	g.populateCache(key, value)
+}
+
+func (g *Group) removeLocally(key string) {
+	g.c.remove(key)
+	g.hot.remove(key)
+}
+
+func (g *Group) invalidateLocally(prefix string) {
+	g.c.removePrefix(prefix)
+	g.hot.removePrefix(prefix)
+}
+
+// CacheStats returns the stats of the main cache or the hot cache
+func (g *Group) CacheStats(which CacheType) CacheStats {
+	switch which {
+	case MainCache:
+		return g.c.stats()
+	case HotCache:
+		return g.hot.stats()
+	}
+	return CacheStats{}
+}
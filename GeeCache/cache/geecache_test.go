@@ -1,9 +1,17 @@
 package cache
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	pb "github.com/MarkRepo/Gee/GeeCache/cache/cachepb"
 )
 
 var db = map[string]string{
@@ -28,15 +36,265 @@ func TestGet(t *testing.T) {
 		}))
 
 	for k, v := range db {
-		if view, err := gee.Get(k); err != nil || view.String() != v {
+		if view, err := gee.Get(context.Background(), k); err != nil || view.String() != v {
 			t.Fatal("failed to get value of Tom")
 		} // load from callback function
-		if _, err := gee.Get(k); err != nil || loadCounts[k] > 1 {
+		if _, err := gee.Get(context.Background(), k); err != nil || loadCounts[k] > 1 {
 			t.Fatalf("cache %s miss", k)
 		} // cache hit
 	}
 
-	if view, err := gee.Get("unknown"); err == nil {
+	if view, err := gee.Get(context.Background(), "unknown"); err == nil {
 		t.Fatalf("the value of unknow should be empty, but %s got", view)
 	}
 }
+
+func TestGetTTL(t *testing.T) {
+	loads := 0
+	gee := NewGroup("ttl", 2<<10, GetterWithTTLFunc(
+		func(key string) ([]byte, time.Duration, error) {
+			loads++
+			if key == "short" {
+				return []byte("v"), 10 * time.Millisecond, nil
+			}
+			return []byte("v"), 0, nil
+		}), GroupOptions{TTL: time.Hour})
+	defer gee.Close()
+
+	view, err := gee.Get(context.Background(), "short")
+	if err != nil || view.TTL() <= 0 || view.TTL() > 10*time.Millisecond {
+		t.Fatalf("unexpected ttl %v of short, err %v", view.TTL(), err)
+	}
+	if view, _ := gee.Get(context.Background(), "default"); view.TTL() <= 59*time.Minute {
+		t.Fatalf("default ttl should be used, got %v", view.TTL())
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, _ = gee.Get(context.Background(), "short"); loads != 3 {
+		t.Fatalf("expired key should be reloaded, loads %d", loads)
+	}
+	if _, _ = gee.Get(context.Background(), "default"); loads != 3 {
+		t.Fatalf("key default should be cached, loads %d", loads)
+	}
+}
+
+func TestGroupClose(t *testing.T) {
+	gee := NewGroup("close", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}), GroupOptions{TTL: time.Minute, CleanupInterval: time.Millisecond})
+	if _, err := gee.Get(context.Background(), "Tom"); err != nil {
+		t.Fatal(err)
+	}
+	gee.Close()
+	gee.Close()
+	select {
+	case <-gee.c.done:
+	default:
+		t.Fatal("janitor should be stopped")
+	}
+	if GetGroup("close") != nil {
+		t.Fatal("closed group should be unregistered")
+	}
+}
+
+func TestTTLMillis(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		view   ByteView
+		expect int64
+	}{
+		{ByteView{}, 0},
+		{ByteView{e: now.Add(100 * time.Microsecond)}, 1},
+		{ByteView{e: now.Add(-time.Second)}, 1},
+		{ByteView{e: now.Add(time.Hour)}, time.Hour.Milliseconds()},
+	}
+	for _, c := range cases {
+		if ms := c.view.ttlMillis(); ms != c.expect {
+			t.Fatalf("expire at %v: expect ttl %dms, got %d", c.view.e, c.expect, ms)
+		}
+	}
+}
+
+// fakeOps 记录 fakePeer 收到的请求，broadcast 并发调用各节点
+type fakeOps struct {
+	mu  sync.Mutex
+	ops []string
+}
+
+// take 返回排序后的请求并清空
+func (o *fakeOps) take() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	ops := o.ops
+	o.ops = nil
+	sort.Strings(ops)
+	return ops
+}
+
+// fakePeer 记录收到的请求，同时作为 PeerPicker 把所有 key 分配给 owner；slow 为 true 时一直等到 ctx 结束
+type fakePeer struct {
+	name string
+	ops  *fakeOps
+	slow bool
+}
+
+func (p *fakePeer) Get(ctx context.Context, in *pb.Request, out *pb.Response) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (p *fakePeer) Update(ctx context.Context, in *pb.Request, out *pb.Response) error {
+	if p.slow {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	p.ops.mu.Lock()
+	defer p.ops.mu.Unlock()
+	p.ops.ops = append(p.ops.ops, fmt.Sprintf("%s %v %s", p.name, in.Op, in.Key))
+	return nil
+}
+
+type fakePicker struct {
+	owner *fakePeer
+	peers []PeerGetter
+}
+
+func (p *fakePicker) PickPeer(key string) (PeerGetter, bool) {
+	if key == "local" {
+		return nil, false
+	}
+	return p.owner, true
+}
+
+func (p *fakePicker) ListPeers() []PeerGetter {
+	return p.peers
+}
+
+func TestSetRemoveInvalidate(t *testing.T) {
+	ops := &fakeOps{}
+	a, b := &fakePeer{name: "a", ops: ops}, &fakePeer{name: "b", ops: ops}
+	gee := NewGroup("update", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return nil, fmt.Errorf("%s not exist", key)
+	}))
+	gee.RegisterPeers(&fakePicker{owner: a, peers: []PeerGetter{a, b}})
+	ctx := context.Background()
+
+	if err := gee.Set(ctx, "local", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if view, err := gee.Get(ctx, "local"); err != nil || view.String() != "1" {
+		t.Fatalf("local owner should store the value, got %v %v", view, err)
+	}
+	for _, step := range []struct {
+		fn     func() error
+		expect []string
+	}{
+		{nil, []string{"a REMOVE local", "b REMOVE local"}},
+		{func() error { return gee.Set(ctx, "remote", []byte("2")) }, []string{"a SET remote", "b REMOVE remote"}},
+		{func() error { return gee.Remove(ctx, "local") }, []string{"a REMOVE local", "b REMOVE local"}},
+		{func() error { return gee.Invalidate(ctx, "lo") }, []string{"a INVALIDATE lo", "b INVALIDATE lo"}},
+	} {
+		if step.fn != nil {
+			_ = step.fn()
+		}
+		if got := ops.take(); !reflect.DeepEqual(got, step.expect) {
+			t.Fatalf("expect ops %v, got %v", step.expect, got)
+		}
+	}
+	if _, err := gee.Get(ctx, "local"); err == nil {
+		t.Fatal("removed key should be loaded again")
+	}
+}
+
+func TestBroadcastTimeout(t *testing.T) {
+	ops := &fakeOps{}
+	a, b := &fakePeer{name: "a", ops: ops, slow: true}, &fakePeer{name: "b", ops: ops}
+	gee := NewGroup("broadcast", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return nil, fmt.Errorf("%s not exist", key)
+	}))
+	gee.RegisterPeers(&fakePicker{owner: a, peers: []PeerGetter{a, b}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	// 慢节点只影响自己，其他节点同时收到请求
+	if err := gee.Invalidate(ctx, "k"); err != context.DeadlineExceeded {
+		t.Fatalf("expect deadline exceeded, got %v", err)
+	}
+	if got := ops.take(); !reflect.DeepEqual(got, []string{"b INVALIDATE k"}) {
+		t.Fatalf("fast peer should receive the update, got %v", got)
+	}
+}
+
+// remotePeer 总是返回 key 作为 value，记录请求次数
+type remotePeer struct {
+	gets int
+}
+
+func (p *remotePeer) Get(ctx context.Context, in *pb.Request, out *pb.Response) error {
+	p.gets++
+	out.Value = []byte(in.Key)
+	return nil
+}
+
+func (p *remotePeer) PickPeer(key string) (PeerGetter, bool) {
+	return p, true
+}
+
+func TestHotCache(t *testing.T) {
+	peer := &remotePeer{}
+	gee := NewGroup("hot", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return nil, fmt.Errorf("%s not exist", key)
+	}), GroupOptions{HotSampleRate: 1})
+	gee.RegisterPeers(peer)
+
+	for i := 0; i < 3; i++ {
+		if view, err := gee.Get(context.Background(), "Tom"); err != nil || view.String() != "Tom" {
+			t.Fatalf("failed to get Tom from peer: %v", err)
+		}
+	}
+	if peer.gets != 1 {
+		t.Fatalf("hot key should be fetched from peer once, got %d", peer.gets)
+	}
+	main, hot := gee.CacheStats(MainCache), gee.CacheStats(HotCache)
+	if main.Hits != 0 || main.Items != 0 || hot.Hits != 2 || hot.Items != 1 {
+		t.Fatalf("unexpected stats main %+v, hot %+v", main, hot)
+	}
+
+	_ = gee.Remove(context.Background(), "Tom")
+	if _, _ = gee.Get(context.Background(), "Tom"); peer.gets != 2 {
+		t.Fatal("removed key should be fetched from peer again")
+	}
+}
+
+func TestGetContext(t *testing.T) {
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	var loads int32
+	gee := NewGroup("context", 2<<10, GetterWithContextFunc(
+		func(ctx context.Context, key string) ([]byte, time.Duration, error) {
+			atomic.AddInt32(&loads, 1)
+			started <- struct{}{}
+			<-release
+			return []byte(key), 0, nil
+		}))
+
+	done := make(chan error)
+	go func() {
+		view, err := gee.Get(context.Background(), "Tom")
+		if err == nil && view.String() != "Tom" {
+			err = fmt.Errorf("unexpected value %s", view)
+		}
+		done <- err
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := gee.Get(ctx, "Tom"); err != context.DeadlineExceeded {
+		t.Fatalf("waiter should give up when ctx is done, got %v", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("shared load should not be cancelled by other waiters: %v", err)
+	}
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Fatalf("concurrent gets should share one load, got %d", n)
+	}
+}
@@ -0,0 +1,278 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	pb "github.com/MarkRepo/Gee/GeeCache/cache/cachepb"
+	"github.com/MarkRepo/Gee/GeeCache/cache/consistenthash"
+)
+
+// healthPath 健康检查的路径，位于 HTTPPool 的 basePath 下，例如 /_geecache/_health
+const healthPath = "_health"
+
+const (
+	defaultHealthCheckInterval = 5 * time.Second
+	defaultProbeBackoff        = time.Second
+	defaultMaxProbeBackoff     = time.Minute
+	defaultFailureThreshold    = 5
+)
+
+// PeerEventType 节点变化的类型
+type PeerEventType int
+
+const (
+	// PeerAdded 通过 Set 或 AddPeers 加入了节点
+	PeerAdded PeerEventType = iota + 1
+	// PeerRemoved 通过 Set 或 RemovePeers 删除了节点
+	PeerRemoved
+	// PeerDown 节点探测失败或熔断，暂时从哈希环上摘除
+	PeerDown
+	// PeerUp 摘除的节点重新探测成功，加回哈希环
+	PeerUp
+)
+
+func (t PeerEventType) String() string {
+	switch t {
+	case PeerAdded:
+		return "added"
+	case PeerRemoved:
+		return "removed"
+	case PeerDown:
+		return "down"
+	case PeerUp:
+		return "up"
+	}
+	return "unknown"
+}
+
+// PeerEvent 描述一次哈希环上生效节点的变化
+type PeerEvent struct {
+	Type  PeerEventType
+	Peers []string
+	Moves []consistenthash.Move // Moves 迁移的 key 区间
+}
+
+// peerState 记录远端节点的健康状态
+type peerState struct {
+	failures  int           // failures 连续失败的请求数
+	down      bool          // down 为 true 时节点不在哈希环上
+	backoff   time.Duration // backoff 节点 down 时距离下一次探测的时间，每次探测失败翻倍
+	nextProbe time.Time     // nextProbe 下一次探测的时间，为零值时不探测
+}
+
+// guardedPeer 统计 Get 的结果，连续 failureThreshold 次连接失败、超时或 502/503/504 后熔断，将节点摘除。
+// RemoteError 说明节点仍然正常工作，不计入失败
+type guardedPeer struct {
+	peer
+	addr string
+	pool *HTTPPool
+}
+
+func (g *guardedPeer) Get(ctx context.Context, in *pb.Request, out *pb.Response) error {
+	err := g.peer.Get(ctx, in, out)
+	// 调用方放弃等待导致的失败与节点无关
+	if ctx.Err() == nil {
+		g.pool.report(g.addr, err)
+	}
+	return err
+}
+
+// Close 关闭与远端节点的连接
+func (g *guardedPeer) Close() error {
+	if c, ok := g.peer.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// report 记录一次请求的结果，must be called without p.mu held
+func (p *HTTPPool) report(addr string, err error) {
+	p.mu.Lock()
+	s := p.states[addr]
+	if s == nil || s.down {
+		p.mu.Unlock()
+		return
+	}
+	if _, ok := err.(*RemoteError); err == nil || ok {
+		s.failures = 0
+		p.mu.Unlock()
+		return
+	}
+	s.failures++
+	var events []PeerEvent
+	if s.failures >= p.failureThreshold {
+		p.Log("circuit breaker of peer %s is open after %d failures", addr, s.failures)
+		events = append(events, p.markDown(addr, time.Now()))
+	}
+	p.mu.Unlock()
+	p.emit(events)
+}
+
+// markDown 将节点从哈希环上摘除，must be called with p.mu held
+func (p *HTTPPool) markDown(addr string, now time.Time) PeerEvent {
+	s := p.states[addr]
+	s.down = true
+	s.failures = 0
+	s.backoff = p.probeBackoff
+	s.nextProbe = now.Add(s.backoff)
+	old := p.peers.Clone()
+	p.peers.Remove(addr)
+	return PeerEvent{Type: PeerDown, Peers: []string{addr}, Moves: consistenthash.Diff(old, p.peers)}
+}
+
+// markUp 将节点加回哈希环，must be called with p.mu held
+func (p *HTTPPool) markUp(addr string, now time.Time) PeerEvent {
+	s := p.states[addr]
+	s.down = false
+	s.failures = 0
+	s.backoff = 0
+	p.scheduleProbe(s, now)
+	old := p.peers.Clone()
+	p.peers.Add(addr)
+	return PeerEvent{Type: PeerUp, Peers: []string{addr}, Moves: consistenthash.Diff(old, p.peers)}
+}
+
+// scheduleProbe 设置正常节点下一次探测的时间，healthInterval 小于 0 时不探测
+func (p *HTTPPool) scheduleProbe(s *peerState, now time.Time) {
+	s.nextProbe = time.Time{}
+	if p.healthInterval > 0 {
+		s.nextProbe = now.Add(p.healthInterval)
+	}
+}
+
+// emit 记录日志并通知 onPeerEvent，must be called without p.mu held
+func (p *HTTPPool) emit(events []PeerEvent) {
+	for _, ev := range events {
+		var moved float64
+		for _, mv := range ev.Moves {
+			moved += mv.Fraction()
+		}
+		p.Log("peers %s: %v, %.1f%% of keys moved", ev.Type, ev.Peers, moved*100)
+		if p.onPeerEvent != nil {
+			p.onPeerEvent(ev)
+		}
+	}
+}
+
+// healthLoop 定期探测到期的节点：正常的节点每 healthInterval 探测一次，down 的节点按 backoff 重新探测
+func (p *HTTPPool) healthLoop() {
+	tick := p.probeBackoff
+	if p.healthInterval > 0 && p.healthInterval < tick {
+		tick = p.healthInterval
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case now := <-ticker.C:
+			p.probeDue(now)
+		}
+	}
+}
+
+// probeDue 并发探测所有到期的节点，根据结果摘除或加回节点
+func (p *HTTPPool) probeDue(now time.Time) {
+	type target struct {
+		addr  string
+		state *peerState
+		down  bool
+	}
+	p.mu.Lock()
+	var due []target
+	for addr, s := range p.states {
+		if !s.nextProbe.IsZero() && !now.Before(s.nextProbe) {
+			due = append(due, target{addr: addr, state: s, down: s.down})
+		}
+	}
+	p.mu.Unlock()
+	if len(due) == 0 {
+		return
+	}
+
+	results := make([]error, len(due))
+	var wg sync.WaitGroup
+	for i, t := range due {
+		wg.Add(1)
+		go func(i int, addr string) {
+			defer wg.Done()
+			results[i] = p.probe(addr)
+		}(i, t.addr)
+	}
+	wg.Wait()
+
+	now = time.Now()
+	var events []PeerEvent
+	p.mu.Lock()
+	for i, t := range due {
+		addr, s := t.addr, p.states[t.addr]
+		if s != t.state || s.down != t.down {
+			// 探测期间节点被删除、重新加入或者被熔断摘除，结果已经过时
+			continue
+		}
+		err := results[i]
+		switch {
+		case err == nil && s.down:
+			events = append(events, p.markUp(addr, now))
+		case err == nil:
+			p.scheduleProbe(s, now)
+		case s.down:
+			p.Log("peer %s is still down: %v", addr, err)
+			s.backoff *= 2
+			if s.backoff > p.maxProbeBackoff {
+				s.backoff = p.maxProbeBackoff
+			}
+			s.nextProbe = now.Add(s.backoff)
+		default:
+			p.Log("health check of peer %s failed: %v", addr, err)
+			events = append(events, p.markDown(addr, now))
+		}
+	}
+	p.mu.Unlock()
+	p.emit(events)
+}
+
+// probe 请求远端节点的健康检查路径
+func (p *HTTPPool) probe(addr string) error {
+	ctx, cancel := withTimeout(context.Background(), p.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr+p.basePath+healthPath, nil)
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned: %v", res.Status)
+	}
+	return nil
+}
+
+func serveHealth(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = io.WriteString(w, "ok\n")
+}
+
+// Close stops health checking and closes connections to all peers
+func (p *HTTPPool) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.done)
+	})
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, getter := range p.getters {
+		if c, ok := getter.(io.Closer); ok {
+			_ = c.Close()
+		}
+	}
+	return nil
+}
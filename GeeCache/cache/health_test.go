@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/MarkRepo/Gee/GeeCache/cache/cachepb"
+)
+
+// newFlakyPeer 返回一个可以切换健康状态的节点，不健康时所有请求返回 503
+func newFlakyPeer(healthy *int32) *httptest.Server {
+	pool := NewHTTPPool("peer")
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(healthy) == 0 {
+			http.Error(w, "unhealthy", http.StatusServiceUnavailable)
+			return
+		}
+		pool.ServeHTTP(w, r)
+	}))
+}
+
+func waitEvent(t *testing.T, events chan PeerEvent, typ PeerEventType) PeerEvent {
+	t.Helper()
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type == typ {
+				return ev
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timeout waiting for peer event %s", typ)
+		}
+	}
+}
+
+func TestHTTPPoolCircuitBreaker(t *testing.T) {
+	NewGroup("health", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		if key == "missing" {
+			return nil, fmt.Errorf("%s not exist", key)
+		}
+		return []byte(key), nil
+	}))
+	healthy := int32(1)
+	srv := newFlakyPeer(&healthy)
+	defer srv.Close()
+
+	events := make(chan PeerEvent, 16)
+	pool := NewHTTPPool("self", HTTPPoolOptions{
+		ProbeBackoff:     10 * time.Millisecond,
+		MaxProbeBackoff:  40 * time.Millisecond,
+		FailureThreshold: 2,
+		OnPeerEvent:      func(ev PeerEvent) { events <- ev },
+	})
+	defer pool.Close()
+	pool.Set("self", srv.URL)
+	waitEvent(t, events, PeerAdded)
+
+	var key string
+	var peer PeerGetter
+	for i := 0; peer == nil; i++ {
+		key = fmt.Sprintf("key%d", i)
+		peer, _ = pool.PickPeer(key)
+	}
+	if err := peer.Get(context.Background(), &pb.Request{Group: "health", Key: key}, &pb.Response{}); err != nil {
+		t.Fatal(err)
+	}
+	// 节点正常返回的错误不触发熔断
+	for i := 0; i < 3; i++ {
+		err := peer.Get(context.Background(), &pb.Request{Group: "health", Key: "missing"}, &pb.Response{})
+		if _, ok := err.(*RemoteError); !ok {
+			t.Fatalf("missing key should return RemoteError, got %v", err)
+		}
+	}
+	if _, ok := pool.PickPeer(key); !ok {
+		t.Fatal("misses should not mark the peer down")
+	}
+
+	atomic.StoreInt32(&healthy, 0)
+	for i := 0; i < 2; i++ {
+		if err := peer.Get(context.Background(), &pb.Request{Group: "health", Key: key}, &pb.Response{}); err == nil {
+			t.Fatal("unhealthy peer should fail")
+		}
+	}
+	if ev := waitEvent(t, events, PeerDown); len(ev.Moves) == 0 || ev.Moves[0].From != srv.URL {
+		t.Fatalf("keys of down peer should move, got %+v", ev)
+	}
+	if _, ok := pool.PickPeer(key); ok {
+		t.Fatal("down peer should not be picked")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	pool.mu.Lock()
+	backoff := pool.states[srv.URL].backoff
+	pool.mu.Unlock()
+	if backoff != 40*time.Millisecond {
+		t.Fatalf("backoff should grow to the max, got %v", backoff)
+	}
+
+	atomic.StoreInt32(&healthy, 1)
+	waitEvent(t, events, PeerUp)
+	if _, ok := pool.PickPeer(key); !ok {
+		t.Fatal("recovered peer should be picked again")
+	}
+}
+
+func TestHTTPPoolHealthCheck(t *testing.T) {
+	healthy := int32(1)
+	srv := newFlakyPeer(&healthy)
+	defer srv.Close()
+
+	events := make(chan PeerEvent, 16)
+	pool := NewHTTPPool("self", HTTPPoolOptions{
+		HealthCheckInterval: 10 * time.Millisecond,
+		ProbeBackoff:        10 * time.Millisecond,
+		OnPeerEvent:         func(ev PeerEvent) { events <- ev },
+	})
+	defer pool.Close()
+	pool.Set("self", srv.URL)
+
+	atomic.StoreInt32(&healthy, 0)
+	waitEvent(t, events, PeerDown)
+	atomic.StoreInt32(&healthy, 1)
+	waitEvent(t, events, PeerUp)
+}
+
+func TestProbeResultDiscardedAfterStateChange(t *testing.T) {
+	release := make(chan struct{})
+	probing := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probing <- struct{}{}
+		<-release
+		serveHealth(w)
+	}))
+	defer srv.Close()
+
+	events := make(chan PeerEvent, 16)
+	pool := NewHTTPPool("self", HTTPPoolOptions{
+		ProbeBackoff:     time.Minute,
+		FailureThreshold: 1,
+		OnPeerEvent:      func(ev PeerEvent) { events <- ev },
+	})
+	defer pool.Close()
+	pool.Set("self", srv.URL)
+	waitEvent(t, events, PeerAdded)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pool.probeDue(time.Now().Add(time.Hour))
+	}()
+	<-probing
+	// 探测进行中熔断摘除了节点，成功的探测结果不能把节点加回来
+	pool.report(srv.URL, fmt.Errorf("connection refused"))
+	waitEvent(t, events, PeerDown)
+	close(release)
+	<-done
+
+	select {
+	case ev := <-events:
+		t.Fatalf("stale probe result should be discarded, got event %s", ev.Type)
+	default:
+	}
+	pool.mu.Lock()
+	down := pool.states[srv.URL].down
+	pool.mu.Unlock()
+	if !down {
+		t.Fatal("peer should stay down until the next probe")
+	}
+}
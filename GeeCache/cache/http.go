@@ -1,40 +1,135 @@
 package cache
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 
 	pb "github.com/MarkRepo/Gee/GeeCache/cache/cachepb"
 	"github.com/MarkRepo/Gee/GeeCache/cache/consistenthash"
+	"github.com/MarkRepo/Gee/GeeRPC/rpc"
 )
 
 const (
 	defaultBasePath = "/_geecache/"
 	defaultReplicas = 50
+	defaultTimeout  = 5 * time.Second
 )
 
+// Transport 请求远端节点使用的协议
+type Transport int
+
+const (
+	// HTTPTransport 每次请求发送一个 HTTP 请求
+	HTTPTransport Transport = iota
+	// RPCTransport 通过 GeeRPC 调用远端节点的 GroupCache 服务，每个节点复用一个长连接
+	RPCTransport
+)
+
+// HTTPPoolOptions HTTPPool 的可选配置
+type HTTPPoolOptions struct {
+	BasePath  string        // BasePath 节点间通信的 url 前缀，默认 "/_geecache/"
+	Replicas  int           // Replicas 一致性哈希中每个节点的虚拟节点数，默认 50
+	Timeout   time.Duration // Timeout 每次请求远端节点的超时时间，默认 5 秒，小于 0 表示只受调用方 ctx 限制
+	Transport Transport     // Transport 请求远端节点使用的协议，默认 HTTPTransport，服务端总是同时支持两种协议
+
+	HealthCheckInterval time.Duration   // HealthCheckInterval 主动探测正常节点的间隔，默认 5 秒，小于 0 表示只探测已经摘除的节点
+	ProbeBackoff        time.Duration   // ProbeBackoff 节点摘除后第一次重新探测的等待时间，之后每次失败翻倍，默认 1 秒
+	MaxProbeBackoff     time.Duration   // MaxProbeBackoff 重新探测的最长等待时间，默认 1 分钟
+	FailureThreshold    int             // FailureThreshold 连续多少次 Get 失败后熔断，将节点摘除，默认 5
+	OnPeerEvent         func(PeerEvent) // OnPeerEvent 哈希环上生效的节点变化时调用，调用时不持有 pool 的锁
+}
+
 // HTTPPool implements PeerPicker for a pool of HTTP peers.
-// 实现节点间通信：1. http缓存服务端 2. 使用一致性哈希实现节点选择 3. http客户端 PeerGetter
+// 实现节点间通信：1. http缓存服务端 2. 使用一致性哈希实现节点选择 3. http 或 GeeRPC 客户端 PeerGetter
 type HTTPPool struct {
-	self        string     // 自身地址
-	basePath    string     // url 前缀
-	mu          sync.Mutex // guards peers and httpGetters
-	peers       *consistenthash.Map
-	httpGetters map[string]*httpGetter // keyed by e.g. "http://10.0.0.2:8008"
+	self      string        // 自身地址
+	basePath  string        // url 前缀
+	replicas  int           // 虚拟节点数
+	timeout   time.Duration // 请求远端节点的超时时间
+	transport Transport     // 请求远端节点使用的协议
+	rpcServer *rpc.Server   // 处理其他节点通过 CONNECT 建立的 GeeRPC 连接
+
+	healthInterval   time.Duration
+	probeBackoff     time.Duration
+	maxProbeBackoff  time.Duration
+	failureThreshold int
+	onPeerEvent      func(PeerEvent)
+	healthOnce       sync.Once     // healthOnce 第一次加入远端节点时启动 healthLoop
+	closeOnce        sync.Once     // closeOnce guards done
+	done             chan struct{} // done 关闭时停止 healthLoop
+
+	mu      sync.Mutex          // guards peers, getters and states
+	peers   *consistenthash.Map // peers 只包含没有被摘除的节点
+	getters map[string]peer     // keyed by e.g. "http://10.0.0.2:8008"
+	states  map[string]*peerState
+}
+
+// peer 是远端节点的客户端
+type peer interface {
+	PeerGetter
+	PeerUpdater
 }
 
 // NewHTTPPool initializes an HTTP pool of peers.
-func NewHTTPPool(self string) *HTTPPool {
+func NewHTTPPool(self string, opts ...HTTPPoolOptions) *HTTPPool {
+	var opt HTTPPoolOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.BasePath == "" {
+		opt.BasePath = defaultBasePath
+	}
+	if opt.Replicas <= 0 {
+		opt.Replicas = defaultReplicas
+	}
+	if opt.Timeout == 0 {
+		opt.Timeout = defaultTimeout
+	}
+	if opt.HealthCheckInterval == 0 {
+		opt.HealthCheckInterval = defaultHealthCheckInterval
+	}
+	if opt.ProbeBackoff <= 0 {
+		opt.ProbeBackoff = defaultProbeBackoff
+	}
+	if opt.MaxProbeBackoff < opt.ProbeBackoff {
+		opt.MaxProbeBackoff = defaultMaxProbeBackoff
+		if opt.MaxProbeBackoff < opt.ProbeBackoff {
+			opt.MaxProbeBackoff = opt.ProbeBackoff
+		}
+	}
+	if opt.FailureThreshold <= 0 {
+		opt.FailureThreshold = defaultFailureThreshold
+	}
 	return &HTTPPool{
-		self:     self,
-		basePath: defaultBasePath,
+		self:      self,
+		basePath:  opt.BasePath,
+		replicas:  opt.Replicas,
+		timeout:   opt.Timeout,
+		transport: opt.Transport,
+		rpcServer: newRPCServer(),
+
+		healthInterval:   opt.HealthCheckInterval,
+		probeBackoff:     opt.ProbeBackoff,
+		maxProbeBackoff:  opt.MaxProbeBackoff,
+		failureThreshold: opt.FailureThreshold,
+		onPeerEvent:      opt.OnPeerEvent,
+		done:             make(chan struct{}),
+
+		peers:   consistenthash.New(opt.Replicas, nil),
+		getters: make(map[string]peer),
+		states:  make(map[string]*peerState),
 	}
 }
 
@@ -46,10 +141,27 @@ func (p *HTTPPool) Log(format string, v ...interface{}) {
 // ServeHTTP handle all http requests
 // 缓存服务端实现
 func (p *HTTPPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		// 使用 RPCTransport 的节点通过 CONNECT 建立 GeeRPC 连接
+		p.rpcServer.ServeHTTP(w, r)
+		return
+	}
 	if !strings.HasPrefix(r.URL.Path, p.basePath) {
 		panic("HTTPPool serving unexpected path: " + r.URL.Path)
 	}
 	p.Log("%s %s", r.Method, r.URL.Path)
+	if r.URL.Path == p.basePath+statsPath {
+		serveStats(w, r)
+		return
+	}
+	if r.URL.Path == p.basePath+healthPath {
+		serveHealth(w)
+		return
+	}
+	if r.Method == http.MethodPost {
+		p.serveUpdate(w, r)
+		return
+	}
 	// /<basepath>/<groupname>/<key> required
 	parts := strings.SplitN(r.URL.Path[len(p.basePath):], "/", 2)
 	if len(parts) != 2 {
@@ -65,14 +177,15 @@ func (p *HTTPPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "no such group: "+groupName, http.StatusNotFound)
 		return
 	}
+	atomic.AddInt64(&group.stats.ServerRequests, 1)
 
-	view, err := group.Get(key)
+	view, err := group.Get(r.Context(), key)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	body, err := proto.Marshal(&pb.Response{Value: view.ByteSlice()})
+	body, err := proto.Marshal(&pb.Response{Value: view.ByteSlice(), Ttl: view.ttlMillis()})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -81,17 +194,137 @@ func (p *HTTPPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Write(body)
 }
 
+// serveUpdate 处理 POST 请求，请求体是 pb.Request，包含 Set、Remove、Invalidate 操作
+func (p *HTTPPool) serveUpdate(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &pb.Request{}
+	if err = proto.Unmarshal(body, req); err != nil {
+		http.Error(w, "decoding request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	group := GetGroup(req.Group)
+	if group == nil {
+		http.Error(w, "no such group: "+req.Group, http.StatusNotFound)
+		return
+	}
+	atomic.AddInt64(&group.stats.ServerRequests, 1)
+	if err = group.apply(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	body, _ = proto.Marshal(&pb.Response{})
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(body)
+}
+
 // Set updates the pool's list of peers.
-// 初始化一致性哈希节点选择能力 和 远端节点数据获取 httpGetter
-func (p *HTTPPool) Set(peers ...string) {
+// 初始化一致性哈希节点选择能力 和 远端节点的客户端，仍在列表中的节点保留原有的客户端和连接，返回迁移的 key 区间
+func (p *HTTPPool) Set(peers ...string) []consistenthash.Move {
 	p.mu.Lock()
-	defer p.mu.Unlock()
-	p.peers = consistenthash.New(defaultReplicas, nil)
-	p.peers.Add(peers...)
-	p.httpGetters = make(map[string]*httpGetter, len(peers))
+	keep := make(map[string]bool, len(peers))
+	var added, removed []string
 	for _, peer := range peers {
-		p.httpGetters[peer] = &httpGetter{baseURL: peer + p.basePath}
+		keep[peer] = true
+		if _, ok := p.getters[peer]; !ok {
+			added = append(added, peer)
+		}
+	}
+	for peer := range p.getters {
+		if !keep[peer] {
+			removed = append(removed, peer)
+		}
 	}
+	events := p.change(added, removed)
+	p.mu.Unlock()
+	p.emit(events)
+	return movesOf(events)
+}
+
+// AddPeers adds peers to the pool, peers already in the pool are ignored.
+// 返回迁移到新节点的 key 区间
+func (p *HTTPPool) AddPeers(peers ...string) []consistenthash.Move {
+	p.mu.Lock()
+	var added []string
+	for _, peer := range peers {
+		if _, ok := p.getters[peer]; !ok {
+			added = append(added, peer)
+		}
+	}
+	events := p.change(added, nil)
+	p.mu.Unlock()
+	p.emit(events)
+	return movesOf(events)
+}
+
+// RemovePeers removes peers from the pool and closes their connections.
+// 返回从被删除节点迁移走的 key 区间
+func (p *HTTPPool) RemovePeers(peers ...string) []consistenthash.Move {
+	p.mu.Lock()
+	var removed []string
+	for _, peer := range peers {
+		if _, ok := p.getters[peer]; ok {
+			removed = append(removed, peer)
+		}
+	}
+	events := p.change(nil, removed)
+	p.mu.Unlock()
+	p.emit(events)
+	return movesOf(events)
+}
+
+// change 在哈希环上增删节点，只为新节点创建客户端，must be called with p.mu held
+func (p *HTTPPool) change(added, removed []string) []PeerEvent {
+	var events []PeerEvent
+	if len(removed) > 0 {
+		old := p.peers.Clone()
+		p.peers.Remove(removed...)
+		for _, peer := range removed {
+			if c, ok := p.getters[peer].(io.Closer); ok {
+				_ = c.Close()
+			}
+			delete(p.getters, peer)
+			delete(p.states, peer)
+		}
+		events = append(events, PeerEvent{Type: PeerRemoved, Peers: removed, Moves: consistenthash.Diff(old, p.peers)})
+	}
+	if len(added) > 0 {
+		old := p.peers.Clone()
+		p.peers.Add(added...)
+		now := time.Now()
+		for _, peer := range added {
+			p.getters[peer] = p.newPeer(peer)
+			if peer == p.self {
+				continue
+			}
+			s := &peerState{}
+			p.scheduleProbe(s, now)
+			p.states[peer] = s
+			p.healthOnce.Do(func() { go p.healthLoop() })
+		}
+		events = append(events, PeerEvent{Type: PeerAdded, Peers: added, Moves: consistenthash.Diff(old, p.peers)})
+	}
+	return events
+}
+
+func movesOf(events []PeerEvent) []consistenthash.Move {
+	var moves []consistenthash.Move
+	for _, ev := range events {
+		moves = append(moves, ev.Moves...)
+	}
+	return moves
+}
+
+// newPeer 根据 transport 创建远端节点的客户端
+func (p *HTTPPool) newPeer(addr string) peer {
+	var getter peer = &httpGetter{baseURL: addr + p.basePath, timeout: p.timeout}
+	if p.transport == RPCTransport {
+		getter = newRPCGetter(addr, p.timeout)
+	}
+	return &guardedPeer{peer: getter, addr: addr, pool: p}
 }
 
 // PickPeer picks a peer according to key
@@ -100,38 +333,96 @@ func (p *HTTPPool) PickPeer(key string) (PeerGetter, bool) {
 	defer p.mu.Unlock()
 	if peer := p.peers.Get(key); peer != "" && peer != p.self {
 		p.Log("Pick peer %s", peer)
-		return p.httpGetters[peer], true
+		return p.getters[peer], true
 	}
 	return nil, false
 }
 
+// ListPeers returns the getters of all peers except self
+func (p *HTTPPool) ListPeers() []PeerGetter {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	peers := make([]PeerGetter, 0, len(p.getters))
+	for peer, getter := range p.getters {
+		if peer != p.self {
+			peers = append(peers, getter)
+		}
+	}
+	return peers
+}
+
 type httpGetter struct {
 	baseURL string
+	timeout time.Duration // timeout 小于等于 0 时不限制单次请求的时间
+}
+
+// withTimeout 为单次请求加上超时时间，timeout 小于等于 0 时不限制
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Update 使用 POST 请求将 Set、Remove、Invalidate 操作发送给远端节点
+func (h *httpGetter) Update(ctx context.Context, in *pb.Request, out *pb.Response) error {
+	body, err := proto.Marshal(in)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, h.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.baseURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(res, out)
 }
 
-func (h *httpGetter) Get(in *pb.Request, out *pb.Response) error {
+func (h *httpGetter) Get(ctx context.Context, in *pb.Request, out *pb.Response) error {
 	u := fmt.Sprintf(
 		"%v%v/%v",
 		h.baseURL,
 		url.QueryEscape(in.GetGroup()),
 		url.QueryEscape(in.GetKey()),
 	)
-	res, err := http.Get(u)
+	ctx, cancel := withTimeout(ctx, h.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
 		return err
 	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(res, out)
+}
+
+func decodeResponse(res *http.Response, out *pb.Response) error {
 	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
 		return fmt.Errorf("server returned: %v", res.Status)
+	default:
+		// 远端节点处理了请求，但返回了错误，例如 Getter 找不到 key
+		msg, _ := ioutil.ReadAll(res.Body)
+		return &RemoteError{Msg: fmt.Sprintf("server returned: %v: %s", res.Status, bytes.TrimSpace(msg))}
 	}
 
-	bytes, err := ioutil.ReadAll(res.Body)
+	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		return fmt.Errorf("reading response body: %v", err)
 	}
 
-	if err = proto.Unmarshal(bytes, out); err != nil {
+	if err = proto.Unmarshal(body, out); err != nil {
 		return fmt.Errorf("decoding response body: %v", err)
 	}
 
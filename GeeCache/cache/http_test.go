@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pb "github.com/MarkRepo/Gee/GeeCache/cache/cachepb"
+)
+
+func TestHTTPPoolUpdate(t *testing.T) {
+	NewGroup("http-update", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return nil, fmt.Errorf("%s not exist", key)
+	}))
+	pool := NewHTTPPool("self")
+	srv := httptest.NewServer(pool)
+	defer srv.Close()
+
+	getter := &httpGetter{baseURL: srv.URL + defaultBasePath}
+	if err := getter.Update(context.Background(), &pb.Request{Group: "http-update", Key: "Tom", Op: pb.Op_SET, Value: []byte("630")}, &pb.Response{}); err != nil {
+		t.Fatal(err)
+	}
+	res := &pb.Response{}
+	if err := getter.Get(context.Background(), &pb.Request{Group: "http-update", Key: "Tom"}, res); err != nil || string(res.Value) != "630" {
+		t.Fatalf("value set by peer should be served, got %q %v", res.Value, err)
+	}
+	_ = getter.Update(context.Background(), &pb.Request{Group: "http-update", Key: "T", Op: pb.Op_INVALIDATE}, &pb.Response{})
+	if err := getter.Get(context.Background(), &pb.Request{Group: "http-update", Key: "Tom"}, res); err == nil {
+		t.Fatal("invalidated key should be loaded again")
+	}
+	if err := getter.Update(context.Background(), &pb.Request{Group: "unknown", Op: pb.Op_REMOVE}, &pb.Response{}); err == nil {
+		t.Fatal("unknown group should fail")
+	}
+}
+
+func TestHTTPGetterTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	getter := &httpGetter{baseURL: srv.URL + defaultBasePath, timeout: 20 * time.Millisecond}
+	start := time.Now()
+	if err := getter.Get(context.Background(), &pb.Request{Group: "slow", Key: "Tom"}, &pb.Response{}); err == nil {
+		t.Fatal("slow peer should time out")
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("timeout of peer is not respected")
+	}
+}
+
+func TestHTTPPoolPeers(t *testing.T) {
+	pool := NewHTTPPool("http://a")
+	defer pool.Close()
+	pool.Set("http://a", "http://b", "http://c")
+	b := pool.getters["http://b"]
+
+	moves := pool.AddPeers("http://d")
+	if len(moves) == 0 {
+		t.Fatal("new peer should take some keys")
+	}
+	for _, mv := range moves {
+		if mv.To != "http://d" {
+			t.Fatalf("keys should only move to the new peer, got %+v", mv)
+		}
+	}
+	if pool.getters["http://b"] != b {
+		t.Fatal("existing getters should be kept")
+	}
+	if moves := pool.AddPeers("http://b"); moves != nil {
+		t.Fatalf("adding an existing peer should not move keys, got %v", moves)
+	}
+
+	for _, mv := range pool.RemovePeers("http://d") {
+		if mv.From != "http://d" {
+			t.Fatalf("keys should only move from the removed peer, got %+v", mv)
+		}
+	}
+	if _, ok := pool.getters["http://d"]; ok {
+		t.Fatal("getter of removed peer should be dropped")
+	}
+
+	for _, mv := range pool.Set("http://a", "http://b") {
+		if mv.From != "http://c" {
+			t.Fatalf("keys should only move from the removed peer, got %+v", mv)
+		}
+	}
+	if pool.getters["http://b"] != b || len(pool.getters) != 2 {
+		t.Fatal("Set should keep getters of remaining peers")
+	}
+}
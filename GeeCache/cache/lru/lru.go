@@ -1,6 +1,10 @@
 package lru
 
-import "container/list"
+import (
+	"container/list"
+	"strings"
+	"time"
+)
 
 type Cache struct {
 	maxBytes  int64 // maxBytes 最大缓存数据长度，0 表示不限制
@@ -12,8 +16,13 @@ type Cache struct {
 
 // entry 双向链表节点的数据类型
 type entry struct {
-	key   string
-	value Value
+	key    string
+	value  Value
+	expire time.Time // expire 过期时间，零值表示永不过期
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expire.IsZero() && now.After(e.expire)
 }
 
 // Value 值接口
@@ -36,25 +45,35 @@ func New(maxBytes int64, onEvicted OnEvictedFunc) *Cache {
 	}
 }
 
-// Get 根据key获取缓存的value
+// Get 根据key获取缓存的value，已过期的 value 会被删除
 func (c *Cache) Get(key string) (v Value, ok bool) {
 	if element, ok := c.m[key]; ok {
-		c.l.MoveToFront(element)
 		kv := element.Value.(*entry)
+		if kv.expired(time.Now()) {
+			c.removeElement(element)
+			return nil, false
+		}
+		c.l.MoveToFront(element)
 		return kv.value, true
 	}
 	return
 }
 
-// Put 向 Cache 中添加一个k，v
+// Put 向 Cache 中添加一个k，v，永不过期
 func (c *Cache) Put(key string, value Value) {
+	c.PutWithExpire(key, value, time.Time{})
+}
+
+// PutWithExpire 向 Cache 中添加一个k，v，expire 之后过期，零值表示永不过期
+func (c *Cache) PutWithExpire(key string, value Value, expire time.Time) {
 	if ele, ok := c.m[key]; ok {
 		c.l.MoveToFront(ele)
 		kv := ele.Value.(*entry)
 		c.nBytes += int64(value.Len() - kv.value.Len())
 		kv.value = value
+		kv.expire = expire
 	} else {
-		ele := c.l.PushFront(&entry{key, value})
+		ele := c.l.PushFront(&entry{key, value, expire})
 		c.m[key] = ele
 		c.nBytes += int64(len(key) + value.Len())
 	}
@@ -64,18 +83,62 @@ func (c *Cache) Put(key string, value Value) {
 	}
 }
 
+// Bytes 返回当前缓存数据长度
+func (c *Cache) Bytes() int64 {
+	return c.nBytes
+}
+
 // Len 返回元素个数
 func (c *Cache) Len() int {
 	return c.l.Len()
 }
 
+// Remove 删除 key，返回 key 是否存在
+func (c *Cache) Remove(key string) bool {
+	if ele, ok := c.m[key]; ok {
+		c.removeElement(ele)
+		return true
+	}
+	return false
+}
+
+// RemovePrefix 删除所有以 prefix 开头的 key，返回删除的个数
+func (c *Cache) RemovePrefix(prefix string) int {
+	n := 0
+	for key, ele := range c.m {
+		if strings.HasPrefix(key, prefix) {
+			c.removeElement(ele)
+			n++
+		}
+	}
+	return n
+}
+
+// RemoveExpired 删除所有已过期的元素，返回删除的个数，用于后台定期清理
+func (c *Cache) RemoveExpired() int {
+	now := time.Now()
+	n := 0
+	for ele := c.l.Back(); ele != nil; {
+		prev := ele.Prev()
+		if ele.Value.(*entry).expired(now) {
+			c.removeElement(ele)
+			n++
+		}
+		ele = prev
+	}
+	return n
+}
+
 // removeOldest 移除最旧元素
 func (c *Cache) removeOldest() {
 	ele := c.l.Back()
 	if ele == nil {
 		return
 	}
+	c.removeElement(ele)
+}
 
+func (c *Cache) removeElement(ele *list.Element) {
 	c.l.Remove(ele)
 	kv := ele.Value.(*entry)
 	delete(c.m, kv.key)
@@ -3,6 +3,7 @@ package lru
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 type String string
@@ -53,3 +54,20 @@ func TestOnEvicted(t *testing.T) {
 		t.Fatalf("Call OnEvicted failed, expect keys equals to %s", expect)
 	}
 }
+
+func TestExpire(t *testing.T) {
+	lru := New(0, nil)
+	lru.PutWithExpire("key1", String("1234"), time.Now().Add(-time.Second))
+	lru.PutWithExpire("key2", String("1234"), time.Now().Add(time.Hour))
+	lru.PutWithExpire("key3", String("1234"), time.Now().Add(-time.Second))
+	lru.Put("key4", String("1234"))
+	if _, ok := lru.Get("key1"); ok || lru.Len() != 3 {
+		t.Fatalf("expired key1 should be removed on Get")
+	}
+	if n := lru.RemoveExpired(); n != 1 || lru.Len() != 2 {
+		t.Fatalf("RemoveExpired should remove key3, removed %d", n)
+	}
+	if _, ok := lru.Get("key2"); !ok {
+		t.Fatalf("cache hit key2 failed")
+	}
+}
@@ -1,12 +1,38 @@
 package cache
 
-import pb "github.com/MarkRepo/Gee/GeeCache/cache/cachepb"
+import (
+	"context"
 
+	pb "github.com/MarkRepo/Gee/GeeCache/cache/cachepb"
+)
+
+// PeerGetter is the interface that must be implemented by a peer, Get should return once ctx is done.
 type PeerGetter interface {
-	Get(in *pb.Request, out *pb.Response) error
+	Get(ctx context.Context, in *pb.Request, out *pb.Response) error
 }
 
 // PeerPicker is the interface that must be implemented to locate the peer that owns a specific key.
 type PeerPicker interface {
 	PickPeer(key string) (peer PeerGetter, ok bool)
 }
+
+// PeerUpdater is the interface that must be implemented by a peer to apply Set, Remove and Invalidate requests,
+// Update should return once ctx is done.
+type PeerUpdater interface {
+	Update(ctx context.Context, in *pb.Request, out *pb.Response) error
+}
+
+// PeerLister is implemented by a PeerPicker that can list all remote peers, it is used to broadcast updates.
+type PeerLister interface {
+	ListPeers() []PeerGetter
+}
+
+// RemoteError is returned by a PeerGetter when the peer handled the request but failed,
+// e.g. the Getter of the peer could not find the key. It does not mean the peer is unhealthy.
+type RemoteError struct {
+	Msg string
+}
+
+func (e *RemoteError) Error() string {
+	return e.Msg
+}
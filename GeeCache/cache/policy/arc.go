@@ -0,0 +1,102 @@
+package policy
+
+import (
+	"time"
+
+	"github.com/MarkRepo/Gee/GeeCache/cache/lru"
+)
+
+// ARC (Adaptive Replacement Cache) 同时维护最近访问过一次(t1)和多次(t2)的两个 LRU 队列，
+// 并用 b1、b2 记录最近从 t1、t2 淘汰的 key，根据 ghost 命中自适应地调整 t1 的目标大小 p，
+// 一次性的顺序扫描只会进入 t1，不会冲掉 t2 中的热点数据
+type ARC struct {
+	store
+	p      int64 // p t1 的目标字节数
+	t1, t2 queue
+	b1, b2 *ghosts
+}
+
+// NewARC 创建并初始化 ARC cache
+func NewARC(maxBytes int64, onEvicted lru.OnEvictedFunc) *ARC {
+	return &ARC{store: newStore(maxBytes, onEvicted), b1: newGhosts(), b2: newGhosts()}
+}
+
+// Get 根据key获取缓存的value，命中后移动到 t2
+func (c *ARC) Get(key string) (v lru.Value, ok bool) {
+	ele, ok := c.lookup(key)
+	if !ok {
+		return nil, false
+	}
+	c.move(ele, &c.t2)
+	return ele.Value.(*entry).value, true
+}
+
+// PutWithExpire 向 Cache 中添加一个k，v，expire 之后过期，零值表示永不过期
+func (c *ARC) PutWithExpire(key string, value lru.Value, expire time.Time) {
+	if ele, ok := c.m[key]; ok {
+		c.update(ele, value, expire)
+		c.move(ele, &c.t2)
+		c.replace(false, 0)
+		return
+	}
+
+	e := newEntry(key, value, expire)
+	switch {
+	case c.b1.contains(key):
+		// 最近从 t1 淘汰的 key 又被访问，增大 t1
+		c.p = min64(c.maxBytes, c.p+max64(1, c.b2.bytes/max64(1, c.b1.bytes))*e.size)
+		c.b1.delete(key)
+		c.replace(false, e.size)
+		c.insert(&c.t2, e)
+	case c.b2.contains(key):
+		// 最近从 t2 淘汰的 key 又被访问，减小 t1
+		c.p = max64(0, c.p-max64(1, c.b1.bytes/max64(1, c.b2.bytes))*e.size)
+		c.b2.delete(key)
+		c.replace(true, e.size)
+		c.insert(&c.t2, e)
+	default:
+		c.replace(false, e.size)
+		c.insert(&c.t1, e)
+	}
+	c.trimGhosts()
+}
+
+// replace 淘汰 t1 或 t2 中的元素，直到能够放下 size 字节
+func (c *ARC) replace(inB2 bool, size int64) {
+	for c.full(size) && c.Len() > 0 {
+		if c.t1.len() > 0 && (c.t1.bytes > c.p || (inB2 && c.t1.bytes == c.p) || c.t2.len() == 0) {
+			e := c.removeElement(c.t1.back())
+			c.b1.add(e.key, e.size)
+		} else {
+			e := c.removeElement(c.t2.back())
+			c.b2.add(e.key, e.size)
+		}
+	}
+}
+
+// trimGhosts 限制 ghost 的大小: t1 + b1 不超过容量，全部队列不超过两倍容量
+func (c *ARC) trimGhosts() {
+	if c.maxBytes == 0 {
+		return
+	}
+	for c.b1.len() > 0 && c.t1.bytes+c.b1.bytes > c.maxBytes {
+		c.b1.removeOldest()
+	}
+	for c.b2.len() > 0 && c.t1.bytes+c.t2.bytes+c.b1.bytes+c.b2.bytes > 2*c.maxBytes {
+		c.b2.removeOldest()
+	}
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,82 @@
+package policy
+
+import (
+	"container/list"
+	"time"
+
+	"github.com/MarkRepo/Gee/GeeCache/cache/lru"
+)
+
+// LFU 淘汰访问次数最少的元素，次数相同时淘汰最久未访问的元素，所有操作都是 O(1)
+// freqs 按访问次数从小到大排列，每个访问次数对应一个 LRU 队列
+type LFU struct {
+	store
+	freqs list.List
+}
+
+// freqNode freqs 链表节点的数据类型
+type freqNode struct {
+	n int
+	q queue
+}
+
+// NewLFU 创建并初始化 LFU cache
+func NewLFU(maxBytes int64, onEvicted lru.OnEvictedFunc) *LFU {
+	c := &LFU{store: newStore(maxBytes, onEvicted)}
+	c.unlinked = c.unlinkFreq
+	return c
+}
+
+// Get 根据key获取缓存的value，访问次数加一
+func (c *LFU) Get(key string) (v lru.Value, ok bool) {
+	ele, ok := c.lookup(key)
+	if !ok {
+		return nil, false
+	}
+	c.touch(ele)
+	return ele.Value.(*entry).value, true
+}
+
+// PutWithExpire 向 Cache 中添加一个k，v，expire 之后过期，零值表示永不过期
+func (c *LFU) PutWithExpire(key string, value lru.Value, expire time.Time) {
+	if ele, ok := c.m[key]; ok {
+		c.update(ele, value, expire)
+		c.touch(ele)
+	} else {
+		e := newEntry(key, value, expire)
+		for c.full(e.size) && c.Len() > 0 {
+			c.removeElement(c.freqs.Front().Value.(*freqNode).q.back())
+		}
+		front := c.freqs.Front()
+		if front == nil || front.Value.(*freqNode).n != 1 {
+			front = c.freqs.PushFront(&freqNode{n: 1})
+		}
+		e.freq = front
+		c.insert(&front.Value.(*freqNode).q, e)
+	}
+	for c.full(0) && c.Len() > 0 {
+		c.removeElement(c.freqs.Front().Value.(*freqNode).q.back())
+	}
+}
+
+// touch 将节点移动到访问次数加一的队列
+func (c *LFU) touch(ele *list.Element) {
+	e := ele.Value.(*entry)
+	cur := e.freq
+	next := cur.Next()
+	if next == nil || next.Value.(*freqNode).n != cur.Value.(*freqNode).n+1 {
+		next = c.freqs.InsertAfter(&freqNode{n: cur.Value.(*freqNode).n + 1}, cur)
+	}
+	c.move(ele, &next.Value.(*freqNode).q)
+	e.freq = next
+	if cur.Value.(*freqNode).q.len() == 0 {
+		c.freqs.Remove(cur)
+	}
+}
+
+// unlinkFreq 删除节点后移除空的访问次数队列
+func (c *LFU) unlinkFreq(e *entry) {
+	if e.freq.Value.(*freqNode).q.len() == 0 {
+		c.freqs.Remove(e.freq)
+	}
+}
@@ -0,0 +1,101 @@
+package policy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/MarkRepo/Gee/GeeCache/cache/lru"
+)
+
+type String string
+
+func (d String) Len() int {
+	return len(d)
+}
+
+// cache 与 cache.EvictionPolicy 相同，避免循环引用
+type cache interface {
+	Get(key string) (lru.Value, bool)
+	PutWithExpire(key string, value lru.Value, expire time.Time)
+	Remove(key string) bool
+	RemovePrefix(prefix string) int
+	RemoveExpired() int
+	Len() int
+	Bytes() int64
+}
+
+var policies = map[string]func(maxBytes int64, onEvicted lru.OnEvictedFunc) cache{
+	"LRU":     func(n int64, f lru.OnEvictedFunc) cache { return lru.New(n, f) },
+	"LFU":     func(n int64, f lru.OnEvictedFunc) cache { return NewLFU(n, f) },
+	"ARC":     func(n int64, f lru.OnEvictedFunc) cache { return NewARC(n, f) },
+	"2Q":      func(n int64, f lru.OnEvictedFunc) cache { return New2Q(n, f) },
+	"TinyLFU": func(n int64, f lru.OnEvictedFunc) cache { return NewTinyLFU(n, f) },
+}
+
+func TestPolicies(t *testing.T) {
+	for name, newCache := range policies {
+		t.Run(name, func(t *testing.T) {
+			evicted := 0
+			c := newCache(100, func(string, lru.Value) { evicted++ })
+			for i := 0; i < 50; i++ {
+				c.PutWithExpire(fmt.Sprintf("k%02d", i), String("v"), time.Time{})
+				if _, ok := c.Get(fmt.Sprintf("k%02d", i)); !ok && c.Len() > 0 && name != "TinyLFU" {
+					t.Fatalf("k%02d should be cached right after put", i)
+				}
+			}
+			if c.Bytes() > 100 || c.Len() != int(c.Bytes()/4) || evicted != 50-c.Len() {
+				t.Fatalf("capacity exceeded: %d bytes, %d items, %d evicted", c.Bytes(), c.Len(), evicted)
+			}
+
+			c = newCache(0, nil)
+			c.PutWithExpire("a1", String("1"), time.Time{})
+			c.PutWithExpire("a2", String("2"), time.Now().Add(-time.Second))
+			c.PutWithExpire("b1", String("3"), time.Now().Add(-time.Second))
+			c.PutWithExpire("a1", String("11"), time.Time{})
+			if v, ok := c.Get("a1"); !ok || v.(String) != "11" || c.Bytes() != 4+3+3 {
+				t.Fatalf("updated value should be returned, got %v %d bytes", v, c.Bytes())
+			}
+			if _, ok := c.Get("a2"); ok || c.Len() != 2 {
+				t.Fatal("expired key should be removed on Get")
+			}
+			if n := c.RemoveExpired(); n != 1 || c.Len() != 1 {
+				t.Fatalf("RemoveExpired should remove b1, removed %d", n)
+			}
+			if n := c.RemovePrefix("a"); n != 1 || c.Len() != 0 || c.Bytes() != 0 || c.Remove("a1") {
+				t.Fatalf("RemovePrefix should remove a1, removed %d", n)
+			}
+		})
+	}
+}
+
+// TestScanResistance 热点数据被多次访问后，一次性的顺序扫描不应该把它们全部淘汰
+func TestScanResistance(t *testing.T) {
+	for _, name := range []string{"LFU", "ARC", "2Q", "TinyLFU"} {
+		c := policies[name](400, nil)
+		get := func(key string) bool {
+			if _, ok := c.Get(key); ok {
+				return true
+			}
+			c.PutWithExpire(key, String("v"), time.Time{})
+			return false
+		}
+		for round := 0; round < 5; round++ {
+			for i := 0; i < 20; i++ {
+				get(fmt.Sprintf("h%02d", i))
+			}
+		}
+		for i := 0; i < 1000; i++ {
+			get(fmt.Sprintf("s%03d", i))
+		}
+		hits := 0
+		for i := 0; i < 20; i++ {
+			if get(fmt.Sprintf("h%02d", i)) {
+				hits++
+			}
+		}
+		if hits < 10 {
+			t.Fatalf("%s: hot keys should survive the scan, only %d hits", name, hits)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package policy
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"testing"
+	"time"
+)
+
+// 使用记录的 key 序列回放，比较各淘汰策略的命中率:
+//
+//	go test -run ^$ -bench Replay ./cache/policy -keytrace /path/to/keys.txt
+//
+// -keytrace 需要放在包路径之后，go test 不认识的参数之后的所有参数都会交给测试程序。
+// keytrace 文件每行一个 key，相对路径相对于本包目录，没有指定时使用生成的 zipf 和 zipf+扫描序列。
+// 不能命名为 trace，go test -trace 会把执行 trace 写到该文件，覆盖记录的 key
+var traceFile = flag.String("keytrace", "", "recorded key trace, one key per line")
+
+// replay 按 trace 的顺序访问缓存，未命中时加入缓存，返回命中率
+func replay(c cache, trace []string) float64 {
+	hits := 0
+	for _, key := range trace {
+		if _, ok := c.Get(key); ok {
+			hits++
+			continue
+		}
+		c.PutWithExpire(key, String("v"), time.Time{})
+	}
+	return float64(hits) / float64(len(trace))
+}
+
+func loadTrace(b *testing.B, path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	var trace []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if key := scanner.Text(); key != "" {
+			trace = append(trace, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		b.Fatal(err)
+	}
+	if len(trace) == 0 {
+		b.Fatalf("key trace %s is empty", path)
+	}
+	return trace
+}
+
+// zipfTrace 生成 n 个服从 zipf 分布的 key，scanEvery 大于 0 时每隔 scanEvery 个 key 插入一次长度为 scanLen 的顺序扫描
+func zipfTrace(n, keys, scanEvery, scanLen int) []string {
+	r := rand.New(rand.NewSource(1))
+	z := rand.NewZipf(r, 1.1, 1, uint64(keys-1))
+	trace := make([]string, 0, n)
+	scan := 0
+	for len(trace) < n {
+		if scanEvery > 0 && len(trace)%scanEvery == scanEvery-1 {
+			for i := 0; i < scanLen && len(trace) < n; i++ {
+				trace = append(trace, fmt.Sprintf("scan-%d", scan))
+				scan++
+			}
+			continue
+		}
+		trace = append(trace, fmt.Sprintf("key-%d", z.Uint64()))
+	}
+	return trace
+}
+
+func BenchmarkReplay(b *testing.B) {
+	traces := map[string][]string{}
+	if *traceFile != "" {
+		traces["recorded"] = loadTrace(b, *traceFile)
+	} else {
+		traces["zipf"] = zipfTrace(200000, 50000, 0, 0)
+		traces["zipf+scan"] = zipfTrace(200000, 50000, 20000, 5000)
+	}
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for traceName, trace := range traces {
+		// 容量约为 2000 个 key
+		maxBytes := int64(2000 * (len(trace[0]) + 1))
+		for _, name := range names {
+			b.Run(traceName+"/"+name, func(b *testing.B) {
+				var ratio float64
+				for i := 0; i < b.N; i++ {
+					ratio = replay(policies[name](maxBytes, nil), trace)
+				}
+				b.ReportMetric(ratio*100, "hit%")
+			})
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package policy
+
+import "hash/fnv"
+
+const sketchDepth = 4
+
+// sketch count-min sketch，使用 4 行 4 bit 饱和计数器(这里用 uint8 保存，最大 15)估计 key 的访问频率，
+// 计数总数达到 10 倍宽度时所有计数器减半，使频率随时间衰减
+type sketch struct {
+	rows      [sketchDepth][]uint8
+	mask      uint64
+	additions int
+}
+
+func newSketch(width int) *sketch {
+	s := &sketch{}
+	s.reset(width)
+	return s
+}
+
+func (s *sketch) reset(width int) {
+	n := 16
+	for n < width {
+		n <<= 1
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, n)
+	}
+	s.mask = uint64(n - 1)
+	s.additions = 0
+}
+
+// ensure 元素个数超过宽度时扩大 sketch，已有的计数会被清空
+func (s *sketch) ensure(items int) {
+	if uint64(items) > s.mask+1 {
+		s.reset(items * 2)
+	}
+}
+
+func (s *sketch) index(h uint64, i int) uint64 {
+	return (h + uint64(i)*(h>>32|1)) & s.mask
+}
+
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return h.Sum64()
+}
+
+func (s *sketch) increment(key string) {
+	h := hashKey(key)
+	for i := range s.rows {
+		if c := &s.rows[i][s.index(h, i)]; *c < 15 {
+			*c++
+		}
+	}
+	s.additions++
+	if s.additions >= 10*len(s.rows[0]) {
+		s.halve()
+	}
+}
+
+func (s *sketch) estimate(key string) uint8 {
+	h := hashKey(key)
+	min := uint8(15)
+	for i := range s.rows {
+		if c := s.rows[i][s.index(h, i)]; c < min {
+			min = c
+		}
+	}
+	return min
+}
+
+func (s *sketch) halve() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
@@ -0,0 +1,202 @@
+// Package policy 提供 LRU 以外的缓存淘汰策略: LFU、ARC、2Q 和 W-TinyLFU
+// 与 lru.Cache 一样，容量按 key 和 value 的字节数计算，maxBytes 为 0 表示不限制，
+// 所有实现都不是并发安全的，由调用方加锁
+package policy
+
+import (
+	"container/list"
+	"strings"
+	"time"
+
+	"github.com/MarkRepo/Gee/GeeCache/cache/lru"
+)
+
+// entry 链表节点的数据类型，ghost 节点(只记录 key 的历史)的 value 为 nil
+type entry struct {
+	key    string
+	value  lru.Value
+	expire time.Time // expire 过期时间，零值表示永不过期
+	size   int64     // size len(key) + value.Len()
+	q      *queue    // q 节点所在的队列
+	freq   *list.Element
+}
+
+func newEntry(key string, value lru.Value, expire time.Time) *entry {
+	return &entry{key: key, value: value, expire: expire, size: int64(len(key) + value.Len())}
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expire.IsZero() && now.After(e.expire)
+}
+
+// queue 双向链表，记录链表中节点的总字节数
+type queue struct {
+	l     list.List
+	bytes int64
+}
+
+func (q *queue) pushFront(e *entry) *list.Element {
+	e.q = q
+	q.bytes += e.size
+	return q.l.PushFront(e)
+}
+
+func (q *queue) remove(ele *list.Element) *entry {
+	e := q.l.Remove(ele).(*entry)
+	q.bytes -= e.size
+	e.q = nil
+	return e
+}
+
+func (q *queue) back() *list.Element {
+	return q.l.Back()
+}
+
+func (q *queue) len() int {
+	return q.l.Len()
+}
+
+// store 各策略共用的 key 索引、字节统计、删除和过期处理，m 中只有驻留在缓存中的节点
+type store struct {
+	maxBytes  int64
+	nBytes    int64
+	m         map[string]*list.Element
+	onEvicted lru.OnEvictedFunc
+	unlinked  func(e *entry) // unlinked 节点从队列中删除后调用，用于策略自身的清理
+}
+
+func newStore(maxBytes int64, onEvicted lru.OnEvictedFunc) store {
+	return store{maxBytes: maxBytes, m: make(map[string]*list.Element), onEvicted: onEvicted}
+}
+
+// lookup 查找 key，已过期的节点会被删除
+func (s *store) lookup(key string) (*list.Element, bool) {
+	ele, ok := s.m[key]
+	if !ok {
+		return nil, false
+	}
+	if ele.Value.(*entry).expired(time.Now()) {
+		s.removeElement(ele)
+		return nil, false
+	}
+	return ele, true
+}
+
+// insert 将 e 加入队列 q 的头部
+func (s *store) insert(q *queue, e *entry) {
+	s.m[e.key] = q.pushFront(e)
+	s.nBytes += e.size
+}
+
+// move 将节点移动到队列 q 的头部，返回新的节点
+func (s *store) move(ele *list.Element, q *queue) *list.Element {
+	e := ele.Value.(*entry)
+	e.q.remove(ele)
+	ele = q.pushFront(e)
+	s.m[e.key] = ele
+	return ele
+}
+
+// update 更新已存在节点的 value 和过期时间
+func (s *store) update(ele *list.Element, value lru.Value, expire time.Time) {
+	e := ele.Value.(*entry)
+	size := int64(len(e.key) + value.Len())
+	e.q.bytes += size - e.size
+	s.nBytes += size - e.size
+	e.value, e.expire, e.size = value, expire, size
+}
+
+func (s *store) removeElement(ele *list.Element) *entry {
+	e := ele.Value.(*entry)
+	e.q.remove(ele)
+	delete(s.m, e.key)
+	s.nBytes -= e.size
+	if s.unlinked != nil {
+		s.unlinked(e)
+	}
+	if s.onEvicted != nil {
+		s.onEvicted(e.key, e.value)
+	}
+	return e
+}
+
+// full 返回加入 size 字节后是否超过容量
+func (s *store) full(size int64) bool {
+	return s.maxBytes != 0 && s.nBytes+size > s.maxBytes
+}
+
+// Remove 删除 key，返回 key 是否存在
+func (s *store) Remove(key string) bool {
+	if ele, ok := s.m[key]; ok {
+		s.removeElement(ele)
+		return true
+	}
+	return false
+}
+
+// RemovePrefix 删除所有以 prefix 开头的 key，返回删除的个数
+func (s *store) RemovePrefix(prefix string) int {
+	n := 0
+	for key, ele := range s.m {
+		if strings.HasPrefix(key, prefix) {
+			s.removeElement(ele)
+			n++
+		}
+	}
+	return n
+}
+
+// RemoveExpired 删除所有已过期的元素，返回删除的个数
+func (s *store) RemoveExpired() int {
+	now := time.Now()
+	n := 0
+	for _, ele := range s.m {
+		if ele.Value.(*entry).expired(now) {
+			s.removeElement(ele)
+			n++
+		}
+	}
+	return n
+}
+
+// Len 返回元素个数
+func (s *store) Len() int {
+	return len(s.m)
+}
+
+// Bytes 返回当前缓存数据长度
+func (s *store) Bytes() int64 {
+	return s.nBytes
+}
+
+// ghosts 只记录 key 的历史队列，用于 ARC 和 2Q 判断 key 是否最近被淘汰过
+type ghosts struct {
+	queue
+	m map[string]*list.Element
+}
+
+func newGhosts() *ghosts {
+	return &ghosts{m: make(map[string]*list.Element)}
+}
+
+func (g *ghosts) add(key string, size int64) {
+	g.m[key] = g.pushFront(&entry{key: key, size: size})
+}
+
+func (g *ghosts) contains(key string) bool {
+	_, ok := g.m[key]
+	return ok
+}
+
+func (g *ghosts) delete(key string) {
+	if ele, ok := g.m[key]; ok {
+		g.remove(ele)
+		delete(g.m, key)
+	}
+}
+
+func (g *ghosts) removeOldest() {
+	if ele := g.back(); ele != nil {
+		delete(g.m, g.remove(ele).key)
+	}
+}
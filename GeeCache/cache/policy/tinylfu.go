@@ -0,0 +1,106 @@
+package policy
+
+import (
+	"container/list"
+	"time"
+
+	"github.com/MarkRepo/Gee/GeeCache/cache/lru"
+)
+
+// TinyLFU W-TinyLFU 算法: 新元素先进入占容量 1% 的 LRU 窗口 window，被挤出窗口时与主缓存中
+// 即将被淘汰的元素比较 sketch 估计的访问频率，频率更高的留下。主缓存是 SLRU，
+// 访问过两次以上的元素从 probation 晋升到 protected(占主缓存的 80%)
+type TinyLFU struct {
+	store
+	window, probation, protected queue
+	windowBytes, protectedBytes  int64
+	sketch                       *sketch
+}
+
+// NewTinyLFU 创建并初始化 W-TinyLFU cache
+func NewTinyLFU(maxBytes int64, onEvicted lru.OnEvictedFunc) *TinyLFU {
+	windowBytes := maxBytes / 100
+	if windowBytes == 0 {
+		windowBytes = 1
+	}
+	return &TinyLFU{
+		store:          newStore(maxBytes, onEvicted),
+		windowBytes:    windowBytes,
+		protectedBytes: (maxBytes - windowBytes) * 8 / 10,
+		sketch:         newSketch(1024),
+	}
+}
+
+// Get 根据key获取缓存的value，无论是否命中都会记录一次访问
+func (c *TinyLFU) Get(key string) (v lru.Value, ok bool) {
+	c.sketch.increment(key)
+	ele, ok := c.lookup(key)
+	if !ok {
+		return nil, false
+	}
+	c.touch(ele)
+	return ele.Value.(*entry).value, true
+}
+
+// PutWithExpire 向 Cache 中添加一个k，v，expire 之后过期，零值表示永不过期
+func (c *TinyLFU) PutWithExpire(key string, value lru.Value, expire time.Time) {
+	if ele, ok := c.m[key]; ok {
+		c.update(ele, value, expire)
+		c.touch(ele)
+	} else {
+		c.insert(&c.window, newEntry(key, value, expire))
+		c.sketch.ensure(c.Len())
+	}
+	c.evict()
+}
+
+func (c *TinyLFU) touch(ele *list.Element) {
+	switch ele.Value.(*entry).q {
+	case &c.window:
+		c.move(ele, &c.window)
+	case &c.probation, &c.protected:
+		c.move(ele, &c.protected)
+		for c.protected.bytes > c.protectedBytes && c.protected.len() > 1 {
+			c.move(c.protected.back(), &c.probation)
+		}
+	}
+}
+
+// evict 将超出窗口的元素移入主缓存，主缓存已满时由 sketch 决定淘汰哪一个
+func (c *TinyLFU) evict() {
+	if c.maxBytes == 0 {
+		return
+	}
+	for c.window.bytes > c.windowBytes && c.window.len() > 0 {
+		candidate := c.window.back()
+		if !c.full(0) {
+			c.move(candidate, &c.probation)
+			continue
+		}
+		victim := c.mainVictim()
+		if victim == nil {
+			c.removeElement(candidate)
+			continue
+		}
+		if c.sketch.estimate(candidate.Value.(*entry).key) > c.sketch.estimate(victim.Value.(*entry).key) {
+			c.removeElement(victim)
+		} else {
+			c.removeElement(candidate)
+		}
+	}
+	for c.full(0) && c.Len() > 0 {
+		victim := c.mainVictim()
+		if victim == nil {
+			victim = c.window.back()
+		}
+		c.removeElement(victim)
+	}
+}
+
+// mainVictim 返回主缓存中下一个被淘汰的元素，优先淘汰 probation
+func (c *TinyLFU) mainVictim() *list.Element {
+	if ele := c.probation.back(); ele != nil {
+		return ele
+	}
+	return c.protected.back()
+}
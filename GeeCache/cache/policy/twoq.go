@@ -0,0 +1,71 @@
+package policy
+
+import (
+	"time"
+
+	"github.com/MarkRepo/Gee/GeeCache/cache/lru"
+)
+
+// TwoQueue 2Q 算法: 新加入的元素先进入 FIFO 队列 in，从 in 淘汰的 key 记录在 out 中，
+// 在 in 中再次被访问、或者在 out 中的 key 再次加入时才进入 LRU 队列 hot，只访问一次的扫描数据不会进入 hot
+type TwoQueue struct {
+	store
+	in, hot  queue
+	out      *ghosts
+	inBytes  int64 // inBytes in 的目标字节数，容量的 25%
+	outBytes int64 // outBytes out 记录的 key 对应的最大字节数，容量的 50%
+}
+
+// New2Q 创建并初始化 2Q cache
+func New2Q(maxBytes int64, onEvicted lru.OnEvictedFunc) *TwoQueue {
+	return &TwoQueue{
+		store:    newStore(maxBytes, onEvicted),
+		out:      newGhosts(),
+		inBytes:  maxBytes / 4,
+		outBytes: maxBytes / 2,
+	}
+}
+
+// Get 根据key获取缓存的value，命中后移动到 hot
+func (c *TwoQueue) Get(key string) (v lru.Value, ok bool) {
+	ele, ok := c.lookup(key)
+	if !ok {
+		return nil, false
+	}
+	c.move(ele, &c.hot)
+	return ele.Value.(*entry).value, true
+}
+
+// PutWithExpire 向 Cache 中添加一个k，v，expire 之后过期，零值表示永不过期
+func (c *TwoQueue) PutWithExpire(key string, value lru.Value, expire time.Time) {
+	if ele, ok := c.m[key]; ok {
+		c.update(ele, value, expire)
+		c.move(ele, &c.hot)
+		c.reclaim(0)
+		return
+	}
+
+	e := newEntry(key, value, expire)
+	c.reclaim(e.size)
+	if c.out.contains(key) {
+		c.out.delete(key)
+		c.insert(&c.hot, e)
+	} else {
+		c.insert(&c.in, e)
+	}
+}
+
+// reclaim 淘汰元素直到能够放下 size 字节，in 超过目标大小时优先淘汰 in
+func (c *TwoQueue) reclaim(size int64) {
+	for c.full(size) && c.Len() > 0 {
+		if c.in.len() > 0 && (c.in.bytes > c.inBytes || c.hot.len() == 0) {
+			e := c.removeElement(c.in.back())
+			c.out.add(e.key, e.size)
+			for c.out.len() > 0 && c.out.bytes > c.outBytes {
+				c.out.removeOldest()
+			}
+		} else {
+			c.removeElement(c.hot.back())
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/MarkRepo/Gee/GeeCache/cache/cachepb"
+	"github.com/MarkRepo/Gee/GeeRPC/rpc"
+)
+
+// GroupCache 通过 GeeRPC 为其他节点提供服务，对应 cachepb.proto 中的 service GroupCache
+type GroupCache struct{}
+
+// Get 与 HTTPPool 处理 GET 请求相同，返回 group 中 key 的值和剩余有效期
+func (GroupCache) Get(ctx context.Context, in *pb.Request, out *pb.Response) error {
+	group := GetGroup(in.Group)
+	if group == nil {
+		return fmt.Errorf("no such group: %s", in.Group)
+	}
+	atomic.AddInt64(&group.stats.ServerRequests, 1)
+	view, err := group.Get(ctx, in.Key)
+	if err != nil {
+		return err
+	}
+	out.Value = view.ByteSlice()
+	out.Ttl = view.ttlMillis()
+	return nil
+}
+
+// Update 与 HTTPPool 处理 POST 请求相同，执行 Set、Remove、Invalidate 操作
+func (GroupCache) Update(in *pb.Request, out *pb.Response) error {
+	group := GetGroup(in.Group)
+	if group == nil {
+		return fmt.Errorf("no such group: %s", in.Group)
+	}
+	atomic.AddInt64(&group.stats.ServerRequests, 1)
+	return group.apply(in)
+}
+
+func newRPCServer() *rpc.Server {
+	server := rpc.NewServer()
+	if err := server.Register(GroupCache{}); err != nil {
+		panic(err)
+	}
+	return server
+}
+
+// rpcGetter 通过 GeeRPC 请求远端节点，所有请求复用一个连接，连接断开后在下一次请求时重新建立
+type rpcGetter struct {
+	addr    string        // addr 远端节点的 host:port
+	timeout time.Duration // timeout 小于等于 0 时不限制单次请求的时间
+	mu      sync.Mutex    // guards client and dial
+	client  *rpc.Client
+	dial    *dialCall // dial 正在进行的连接，并发的调用方等待同一次连接的结果
+}
+
+// dialCall 一次正在进行的连接，done 关闭后 client 和 err 可读
+type dialCall struct {
+	done   chan struct{}
+	client *rpc.Client
+	err    error
+}
+
+// newRPCGetter peer 可以是 "http://10.0.0.2:8008" 这样的节点地址，也可以是 host:port
+func newRPCGetter(peer string, timeout time.Duration) *rpcGetter {
+	addr := peer
+	if u, err := url.Parse(peer); err == nil && u.Host != "" {
+		addr = u.Host
+	}
+	return &rpcGetter{addr: addr, timeout: timeout}
+}
+
+// conn 返回可用的连接，通过 HTTP CONNECT 连接到远端节点的 HTTPPool。
+// 连接时不持有 r.mu，其他调用方等待同一次连接的结果，ctx 结束时放弃等待
+func (r *rpcGetter) conn(ctx context.Context) (*rpc.Client, error) {
+	r.mu.Lock()
+	if r.client != nil && r.client.IsAvailable() {
+		r.mu.Unlock()
+		return r.client, nil
+	}
+	d := r.dial
+	if d == nil {
+		if r.client != nil {
+			_ = r.client.Close()
+			r.client = nil
+		}
+		d = &dialCall{done: make(chan struct{})}
+		r.dial = d
+		go r.doDial(d)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-d.done:
+		return d.client, d.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (r *rpcGetter) doDial(d *dialCall) {
+	opt := &rpc.Option{}
+	if r.timeout > 0 {
+		opt.ConnectTimeout = r.timeout
+	}
+	client, err := rpc.DialHTTP("tcp", r.addr, opt)
+
+	r.mu.Lock()
+	if r.dial == d {
+		r.dial = nil
+		r.client = client
+	} else if err == nil {
+		// 连接期间调用了 Close
+		_ = client.Close()
+		client, err = nil, rpc.ErrShutDown
+	}
+	r.mu.Unlock()
+
+	d.client, d.err = client, err
+	close(d.done)
+}
+
+func (r *rpcGetter) call(ctx context.Context, method string, in *pb.Request, out *pb.Response) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	client, err := r.conn(ctx)
+	if err != nil {
+		return err
+	}
+	err = client.Call(ctx, "GroupCache."+method, in, out)
+	if err != nil && ctx.Err() == nil && client.IsAvailable() {
+		// 没有超时，连接也正常，错误来自远端节点的 GroupCache 服务
+		return &RemoteError{Msg: err.Error()}
+	}
+	return err
+}
+
+func (r *rpcGetter) Get(ctx context.Context, in *pb.Request, out *pb.Response) error {
+	return r.call(ctx, "Get", in, out)
+}
+
+func (r *rpcGetter) Update(ctx context.Context, in *pb.Request, out *pb.Response) error {
+	return r.call(ctx, "Update", in, out)
+}
+
+// Close 关闭与远端节点的连接
+func (r *rpcGetter) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dial = nil
+	if r.client == nil {
+		return nil
+	}
+	err := r.client.Close()
+	r.client = nil
+	return err
+}
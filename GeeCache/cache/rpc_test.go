@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pb "github.com/MarkRepo/Gee/GeeCache/cache/cachepb"
+)
+
+func TestRPCGetter(t *testing.T) {
+	NewGroup("rpc", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		if v, ok := db[key]; ok {
+			return []byte(v), nil
+		}
+		return nil, fmt.Errorf("%s not exist", key)
+	}))
+	srv := httptest.NewServer(NewHTTPPool("self"))
+	defer srv.Close()
+
+	pool := NewHTTPPool("self", HTTPPoolOptions{Transport: RPCTransport, Timeout: time.Second})
+	getter, ok := pool.newPeer(srv.URL).(*guardedPeer).peer.(*rpcGetter)
+	if !ok {
+		t.Fatal("RPCTransport should create rpcGetter")
+	}
+	defer getter.Close()
+
+	res := &pb.Response{}
+	if err := getter.Get(context.Background(), &pb.Request{Group: "rpc", Key: "Tom"}, res); err != nil || string(res.Value) != "630" {
+		t.Fatalf("failed to get Tom over rpc, got %q %v", res.Value, err)
+	}
+	client := getter.client
+	if err := getter.Update(context.Background(), &pb.Request{Group: "rpc", Key: "Tom", Op: pb.Op_SET, Value: []byte("100")}, &pb.Response{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := getter.Get(context.Background(), &pb.Request{Group: "rpc", Key: "Tom"}, res); err != nil || string(res.Value) != "100" {
+		t.Fatalf("value set over rpc should be served, got %q %v", res.Value, err)
+	}
+	if getter.client != client {
+		t.Fatal("connection should be reused")
+	}
+	if _, ok := getter.Get(context.Background(), &pb.Request{Group: "unknown", Key: "Tom"}, res).(*RemoteError); !ok {
+		t.Fatal("unknown group should fail with RemoteError")
+	}
+}
+
+func TestRPCGetterSlowDial(t *testing.T) {
+	// 接受连接但不响应 CONNECT，连接一直阻塞
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	getter := newRPCGetter(l.Addr().String(), -1)
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			errs <- getter.Get(ctx, &pb.Request{Group: "rpc", Key: "Tom"}, &pb.Response{})
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			if err != context.DeadlineExceeded {
+				t.Fatalf("expect %v, got %v", context.DeadlineExceeded, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("callers should not be blocked by the pending dial")
+		}
+	}
+	closed := make(chan struct{})
+	go func() {
+		_ = getter.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close should not be blocked by the pending dial")
+	}
+}
@@ -1,12 +1,19 @@
 // Package singlefligh 防止缓存击穿
 package singleflight
 
-import "sync"
+import (
+	"context"
+	"sync"
+	"time"
+)
 
 type call struct {
-	wg  sync.WaitGroup
-	v   interface{}
-	err error
+	done chan struct{}
+	v    interface{}
+	err  error
+
+	waiters int                // waiters 仍在等待结果的 DoContext 调用方数量
+	cancel  context.CancelFunc // cancel 取消 DoContext 中 fn 使用的 ctx
 }
 
 type Group struct {
@@ -23,21 +30,76 @@ func (g *Group) Do(key string, fn func() (interface{}, error)) (interface{}, err
 
 	if c, ok := g.m[key]; ok {
 		g.mu.Unlock()
-		c.wg.Wait()
+		<-c.done
 		return c.v, c.err
 	}
 
-	c := new(call)
-	c.wg.Add(1)
+	c := &call{done: make(chan struct{})}
 	g.m[key] = c
 	g.mu.Unlock()
 
+	g.doCall(c, key, fn)
+	return c.v, c.err
+}
+
+// DoContext 与 Do 相同，但每个调用方都可以在 ctx 结束时放弃等待并返回 ctx.Err()。
+// fn 在单独的 goroutine 中执行，它的 ctx 带有第一个调用方 ctx 中的值，但不会因为某个调用方放弃而取消，
+// 只有所有调用方都放弃等待时才会被取消。
+func (g *Group) DoContext(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	g.mu.Lock()
+	if g.m == nil {
+		g.m = make(map[string]*call)
+	}
+
+	c, ok := g.m[key]
+	if !ok {
+		loadCtx, cancel := context.WithCancel(detached{ctx})
+		c = &call{done: make(chan struct{}), cancel: cancel}
+		g.m[key] = c
+		go func() {
+			defer cancel()
+			g.doCall(c, key, func() (interface{}, error) { return fn(loadCtx) })
+		}()
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.v, c.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 && c.cancel != nil {
+			// 没有调用方在等待了，取消 fn，之后的调用方重新发起加载
+			c.cancel()
+			if g.m[key] == c {
+				delete(g.m, key)
+			}
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (g *Group) doCall(c *call, key string, fn func() (interface{}, error)) {
 	c.v, c.err = fn()
-	c.wg.Done()
+	close(c.done)
 
 	g.mu.Lock()
-	delete(g.m, key)
+	if g.m[key] == c {
+		delete(g.m, key)
+	}
 	g.mu.Unlock()
+}
 
-	return c.v, c.err
+// detached 保留 ctx 中的值，但去掉 deadline 和取消信号
+type detached struct {
+	context.Context
 }
+
+func (detached) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detached) Done() <-chan struct{} { return nil }
+
+func (detached) Err() error { return nil }
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// statsPath 统计信息的路径，位于 HTTPPool 的 basePath 下，例如 /_geecache/_stats
+// 以 ?format=prometheus 或者 Accept: text/plain 请求时返回 Prometheus 文本格式，否则返回 JSON
+const statsPath = "_stats"
+
+// Stats Group 的统计信息，所有字段都是原子计数
+type Stats struct {
+	Gets           int64 `json:"gets"`            // Gets 所有 Get 请求，包括来自远端节点的请求
+	CacheHits      int64 `json:"cache_hits"`      // CacheHits main cache 或 hot cache 命中
+	Loads          int64 `json:"loads"`           // Loads 缓存未命中，需要 load 的次数
+	LoadsDeduped   int64 `json:"loads_deduped"`   // LoadsDeduped singleflight 合并之后实际执行的 load 次数
+	PeerLoads      int64 `json:"peer_loads"`      // PeerLoads 从远端节点获取成功
+	PeerErrors     int64 `json:"peer_errors"`     // PeerErrors 从远端节点获取失败
+	LocalLoads     int64 `json:"local_loads"`     // LocalLoads 通过 Getter 加载成功
+	LocalLoadErrs  int64 `json:"local_load_errs"` // LocalLoadErrs 通过 Getter 加载失败
+	ServerRequests int64 `json:"server_requests"` // ServerRequests 来自远端节点的请求
+}
+
+// Stats returns a snapshot of the group's counters
+func (g *Group) Stats() Stats {
+	return Stats{
+		Gets:           atomic.LoadInt64(&g.stats.Gets),
+		CacheHits:      atomic.LoadInt64(&g.stats.CacheHits),
+		Loads:          atomic.LoadInt64(&g.stats.Loads),
+		LoadsDeduped:   atomic.LoadInt64(&g.stats.LoadsDeduped),
+		PeerLoads:      atomic.LoadInt64(&g.stats.PeerLoads),
+		PeerErrors:     atomic.LoadInt64(&g.stats.PeerErrors),
+		LocalLoads:     atomic.LoadInt64(&g.stats.LocalLoads),
+		LocalLoadErrs:  atomic.LoadInt64(&g.stats.LocalLoadErrs),
+		ServerRequests: atomic.LoadInt64(&g.stats.ServerRequests),
+	}
+}
+
+// groupStats 一个 Group 的全部统计信息，用于输出
+type groupStats struct {
+	Stats
+	MainCache CacheStats `json:"main_cache"`
+	HotCache  CacheStats `json:"hot_cache"`
+}
+
+// allStats 返回所有 Group 的统计信息
+func allStats() map[string]groupStats {
+	mu.RLock()
+	defer mu.RUnlock()
+	all := make(map[string]groupStats, len(groups))
+	for name, g := range groups {
+		all[name] = groupStats{Stats: g.Stats(), MainCache: g.CacheStats(MainCache), HotCache: g.CacheStats(HotCache)}
+	}
+	return all
+}
+
+func serveStats(w http.ResponseWriter, r *http.Request) {
+	all := allStats()
+	if r.URL.Query().Get("format") == "prometheus" || strings.HasPrefix(r.Header.Get("Accept"), "text/plain") {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		writePrometheus(w, all)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(all)
+}
+
+// writePrometheus 按 Prometheus 文本格式输出，group 按名称排序
+func writePrometheus(w io.Writer, all map[string]groupStats) {
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	counters := []struct {
+		name, help string
+		value      func(s groupStats) int64
+	}{
+		{"gets", "Get requests, including requests from peers", func(s groupStats) int64 { return s.Gets }},
+		{"cache_hits", "Get requests served by the main or hot cache", func(s groupStats) int64 { return s.CacheHits }},
+		{"loads", "Get requests that missed the cache", func(s groupStats) int64 { return s.Loads }},
+		{"loads_deduped", "Loads executed after singleflight deduplication", func(s groupStats) int64 { return s.LoadsDeduped }},
+		{"peer_loads", "Values loaded from peers", func(s groupStats) int64 { return s.PeerLoads }},
+		{"peer_errors", "Failed loads from peers", func(s groupStats) int64 { return s.PeerErrors }},
+		{"local_loads", "Values loaded by the getter", func(s groupStats) int64 { return s.LocalLoads }},
+		{"local_load_errs", "Failed loads by the getter", func(s groupStats) int64 { return s.LocalLoadErrs }},
+		{"server_requests", "Requests received from peers", func(s groupStats) int64 { return s.ServerRequests }},
+	}
+	for _, c := range counters {
+		fmt.Fprintf(w, "# HELP geecache_%s_total %s.\n# TYPE geecache_%s_total counter\n", c.name, c.help, c.name)
+		for _, name := range names {
+			fmt.Fprintf(w, "geecache_%s_total{group=%q} %d\n", c.name, name, c.value(all[name]))
+		}
+	}
+
+	caches := []struct {
+		name, help, typ string
+		value           func(s CacheStats) int64
+	}{
+		{"cache_bytes", "Bytes of keys and values in the cache", "gauge", func(s CacheStats) int64 { return s.Bytes }},
+		{"cache_items", "Items in the cache", "gauge", func(s CacheStats) int64 { return s.Items }},
+		{"cache_gets_total", "Lookups in the cache", "counter", func(s CacheStats) int64 { return s.Gets }},
+		{"cache_hits_total", "Hits in the cache", "counter", func(s CacheStats) int64 { return s.Hits }},
+		{"cache_evictions_total", "Items evicted because the cache is full", "counter", func(s CacheStats) int64 { return s.Evictions }},
+	}
+	for _, c := range caches {
+		fmt.Fprintf(w, "# HELP geecache_%s %s.\n# TYPE geecache_%s %s\n", c.name, c.help, c.name, c.typ)
+		for _, name := range names {
+			s := all[name]
+			fmt.Fprintf(w, "geecache_%s{group=%q,cache=\"main\"} %d\n", c.name, name, c.value(s.MainCache))
+			fmt.Fprintf(w, "geecache_%s{group=%q,cache=\"hot\"} %d\n", c.name, name, c.value(s.HotCache))
+		}
+	}
+}
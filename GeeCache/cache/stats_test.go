@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pb "github.com/MarkRepo/Gee/GeeCache/cache/cachepb"
+)
+
+type failingPeer struct{}
+
+func (failingPeer) Get(ctx context.Context, in *pb.Request, out *pb.Response) error {
+	return fmt.Errorf("peer is down")
+}
+
+func (p failingPeer) PickPeer(key string) (PeerGetter, bool) {
+	return p, key != "local"
+}
+
+func TestStats(t *testing.T) {
+	g := NewGroup("stats", 15, GetterFunc(func(key string) ([]byte, error) {
+		if key == "unknown" {
+			return nil, fmt.Errorf("%s not exist", key)
+		}
+		return []byte("value"), nil
+	}), GroupOptions{Shards: 1, HotCacheBytes: -1})
+	g.RegisterPeers(failingPeer{})
+
+	for _, key := range []string{"local", "local", "remote", "unknown", "k2"} {
+		_, _ = g.Get(context.Background(), key)
+	}
+	expect := Stats{Gets: 5, CacheHits: 1, Loads: 4, LoadsDeduped: 4, PeerErrors: 3, LocalLoads: 3, LocalLoadErrs: 1}
+	if s := g.Stats(); s != expect {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+	// 容量 15 字节只能放下一个 key
+	if s := g.CacheStats(MainCache); s.Items != 1 || s.Evictions != 2 || s.Hits != 1 {
+		t.Fatalf("unexpected main cache stats %+v", s)
+	}
+
+	pool := NewHTTPPool("self")
+	w := httptest.NewRecorder()
+	pool.ServeHTTP(w, httptest.NewRequest(http.MethodGet, defaultBasePath+statsPath, nil))
+	var all map[string]groupStats
+	if err := json.Unmarshal(w.Body.Bytes(), &all); err != nil || all["stats"].Gets != 5 || all["stats"].MainCache.Evictions != 2 {
+		t.Fatalf("unexpected json stats %s, err %v", w.Body.String(), err)
+	}
+
+	w = httptest.NewRecorder()
+	pool.ServeHTTP(w, httptest.NewRequest(http.MethodGet, defaultBasePath+statsPath+"?format=prometheus", nil))
+	if !strings.Contains(w.Body.String(), `geecache_peer_errors_total{group="stats"} 3`) ||
+		!strings.Contains(w.Body.String(), `geecache_cache_items{group="stats",cache="main"} 1`) {
+		t.Fatalf("unexpected prometheus stats %s", w.Body.String())
+	}
+}
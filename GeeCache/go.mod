@@ -3,6 +3,12 @@ module github.com/MarkRepo/Gee/GeeCache
 go 1.16
 
 require (
+	github.com/MarkRepo/Gee/GeeRPC v0.0.0
 	github.com/golang/protobuf v1.5.2
 	google.golang.org/protobuf v1.26.0
 )
+
+replace (
+	github.com/MarkRepo/Gee/GeeRPC => ../GeeRPC
+	github.com/MarkRepo/Gee/GeeTrace => ../GeeTrace
+)
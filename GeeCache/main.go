@@ -26,8 +26,8 @@ func createGroup() *cache.Group {
 		}))
 }
 
-func startCacheServer(addr string, addrs []string, gee *cache.Group) {
-	peers := cache.NewHTTPPool(addr)
+func startCacheServer(addr string, addrs []string, gee *cache.Group, transport cache.Transport) {
+	peers := cache.NewHTTPPool(addr, cache.HTTPPoolOptions{Transport: transport})
 	peers.Set(addrs...)
 	gee.RegisterPeers(peers)
 	log.Println("geecache is running at", addr)
@@ -38,7 +38,7 @@ func startAPIServer(apiAddr string, gee *cache.Group) {
 	http.Handle("/api", http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
 			key := r.URL.Query().Get("key")
-			view, err := gee.Get(key)
+			view, err := gee.Get(r.Context(), key)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -52,9 +52,10 @@ func startAPIServer(apiAddr string, gee *cache.Group) {
 
 func main() {
 	var port int
-	var api bool
+	var api, useRPC bool
 	flag.IntVar(&port, "port", 8001, "Geecache server port")
 	flag.BoolVar(&api, "api", false, "Start a api server?")
+	flag.BoolVar(&useRPC, "rpc", false, "Request peers over GeeRPC?")
 	flag.Parse()
 
 	apiAddr := "http://localhost:9999"
@@ -73,5 +74,9 @@ func main() {
 	if api {
 		go startAPIServer(apiAddr, gee)
 	}
-	startCacheServer(addrMap[port], addrs, gee)
+	transport := cache.HTTPTransport
+	if useRPC {
+		transport = cache.RPCTransport
+	}
+	startCacheServer(addrMap[port], addrs, gee, transport)
 }
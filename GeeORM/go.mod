@@ -3,5 +3,8 @@ module github.com/MarkRepo/Gee/GeeORM
 go 1.16
 
 require (
+	github.com/MarkRepo/Gee/GeeTrace v0.0.0
 	github.com/mattn/go-sqlite3 v1.14.4
-)
\ No newline at end of file
+)
+
+replace github.com/MarkRepo/Gee/GeeTrace => ../GeeTrace
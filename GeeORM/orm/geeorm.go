@@ -1,6 +1,7 @@
 package orm
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -54,7 +55,12 @@ func (e *Engine) NewSession() *session.Session {
 type TxFunc func(s *session.Session) (interface{}, error)
 
 func (e *Engine) Transaction(f TxFunc) (result interface{}, err error) {
-	s := e.NewSession()
+	return e.TransactionContext(context.Background(), f)
+}
+
+// TransactionContext is like Transaction, the session passed to f executes SQL with ctx
+func (e *Engine) TransactionContext(ctx context.Context, f TxFunc) (result interface{}, err error) {
+	s := e.NewSession().WithContext(ctx)
 	if err := s.Begin(); err != nil {
 		return nil, err
 	}
@@ -1,10 +1,14 @@
 package log
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"sync"
+
+	"github.com/MarkRepo/Gee/GeeTrace/tracing"
 )
 
 var (
@@ -22,6 +26,26 @@ var (
 	Infof  = infoLog.Printf
 )
 
+// InfoContext logs like Info, the trace id and span id in ctx are prepended if there are
+func InfoContext(ctx context.Context, v ...interface{}) {
+	_ = infoLog.Output(2, fmt.Sprintln(withTrace(ctx, v)...))
+}
+
+// ErrorContext logs like Error, the trace id and span id in ctx are prepended if there are
+func ErrorContext(ctx context.Context, v ...interface{}) {
+	_ = errorLog.Output(2, fmt.Sprintln(withTrace(ctx, v)...))
+}
+
+// withTrace 在日志内容前加上 [trace=xxx span=xxx]
+func withTrace(ctx context.Context, v []interface{}) []interface{} {
+	sc, ok := tracing.SpanContextFromContext(ctx)
+	if !ok {
+		return v
+	}
+	tag := fmt.Sprintf("[trace=%s span=%s]", sc.TraceID, sc.SpanID)
+	return append([]interface{}{tag}, v...)
+}
+
 // log levels
 const (
 	InfoLevel = iota
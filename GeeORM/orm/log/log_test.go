@@ -0,0 +1,30 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/MarkRepo/Gee/GeeTrace/tracing"
+)
+
+func TestInfoContext(t *testing.T) {
+	var buf bytes.Buffer
+	infoLog.SetOutput(&buf)
+	defer infoLog.SetOutput(os.Stdout)
+
+	InfoContext(context.Background(), "SELECT 1")
+	ctx, span := tracing.Start(context.Background(), "query")
+	InfoContext(ctx, "SELECT 2")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || strings.Contains(lines[0], "trace=") || !strings.Contains(lines[0], "log_test.go") {
+		t.Fatalf("unexpected log without trace: %q", buf.String())
+	}
+	tag := "[trace=" + span.SpanContext().TraceID.String() + " span=" + span.SpanContext().SpanID.String() + "] SELECT 2"
+	if !strings.Contains(lines[1], tag) {
+		t.Fatalf("expect %q in %q", tag, lines[1])
+	}
+}
@@ -2,6 +2,7 @@
 package session
 
 import (
+	"context"
 	"database/sql"
 	"strings"
 
@@ -17,6 +18,9 @@ type CommonDB interface {
 	Query(query string, args ...interface{}) (*sql.Rows, error)
 	QueryRow(query string, args ...interface{}) *sql.Row
 	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
 
 // Session op to db
@@ -28,6 +32,7 @@ type Session struct {
 	clause   clause.Clause
 	sql      strings.Builder
 	sqlVars  []interface{}
+	ctx      context.Context // ctx 执行 SQL 使用的 context，其中的链路追踪信息会输出到日志
 }
 
 // New create a new session
@@ -42,6 +47,20 @@ func (s *Session) Clear() {
 	s.clause = clause.Clause{}
 }
 
+// WithContext sets the context used to execute SQL, the trace id in ctx tags the SQL logs
+func (s *Session) WithContext(ctx context.Context) *Session {
+	s.ctx = ctx
+	return s
+}
+
+// Context returns the context set by WithContext, or context.Background()
+func (s *Session) Context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
 // DB return db
 func (s *Session) DB() CommonDB {
 	if s.tx != nil {
@@ -61,9 +80,9 @@ func (s *Session) Raw(sql string, values ...interface{}) *Session {
 // Exec with sql and sqlVars
 func (s *Session) Exec() (result sql.Result, err error) {
 	defer s.Clear()
-	log.Info(s.sql.String(), s.sqlVars)
-	if result, err = s.DB().Exec(s.sql.String(), s.sqlVars...); err != nil {
-		log.Error(err)
+	log.InfoContext(s.Context(), s.sql.String(), s.sqlVars)
+	if result, err = s.DB().ExecContext(s.Context(), s.sql.String(), s.sqlVars...); err != nil {
+		log.ErrorContext(s.Context(), err)
 	}
 	return
 }
@@ -71,16 +90,16 @@ func (s *Session) Exec() (result sql.Result, err error) {
 // QueryRow get a record from db
 func (s *Session) QueryRow() *sql.Row {
 	defer s.Clear()
-	log.Info(s.sql.String(), s.sqlVars)
-	return s.DB().QueryRow(s.sql.String(), s.sqlVars...)
+	log.InfoContext(s.Context(), s.sql.String(), s.sqlVars)
+	return s.DB().QueryRowContext(s.Context(), s.sql.String(), s.sqlVars...)
 }
 
 // QueryRows get records from db
 func (s *Session) QueryRows() (rows *sql.Rows, err error) {
 	defer s.Clear()
-	log.Info(s.sql.String(), s.sqlVars)
-	if rows, err = s.DB().Query(s.sql.String(), s.sqlVars...); err != nil {
-		log.Error(err)
+	log.InfoContext(s.Context(), s.sql.String(), s.sqlVars)
+	if rows, err = s.DB().QueryContext(s.Context(), s.sql.String(), s.sqlVars...); err != nil {
+		log.ErrorContext(s.Context(), err)
 	}
 	return
 }
@@ -3,27 +3,27 @@ package session
 import "github.com/MarkRepo/Gee/GeeORM/orm/log"
 
 func (s *Session) Begin() (err error) {
-	log.Info("transaction begin")
-	if s.tx, err = s.db.Begin(); err != nil {
-		log.Error(err)
+	log.InfoContext(s.Context(), "transaction begin")
+	if s.tx, err = s.db.BeginTx(s.Context(), nil); err != nil {
+		log.ErrorContext(s.Context(), err)
 		return
 	}
 	return
 }
 
 func (s *Session) Commit() error {
-	log.Info("transaction commit")
+	log.InfoContext(s.Context(), "transaction commit")
 	if err := s.tx.Commit(); err != nil {
-		log.Error(err)
+		log.ErrorContext(s.Context(), err)
 		return err
 	}
 	return nil
 }
 
 func (s *Session) Rollback() error {
-	log.Info("transaction rollback")
+	log.InfoContext(s.Context(), "transaction rollback")
 	if err := s.tx.Rollback(); err != nil {
-		log.Error(err)
+		log.ErrorContext(s.Context(), err)
 		return err
 	}
 	return nil
@@ -1,3 +1,7 @@
 module github.com/MarkRepo/Gee/GeeRPC
 
 go 1.16
+
+require github.com/MarkRepo/Gee/GeeTrace v0.0.0
+
+replace github.com/MarkRepo/Gee/GeeTrace => ../GeeTrace
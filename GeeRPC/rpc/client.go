@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/MarkRepo/Gee/GeeRPC/rpc/codec"
+	"github.com/MarkRepo/Gee/GeeTrace/tracing"
 )
 
 // Call represents an active RPC
@@ -25,6 +26,8 @@ type Call struct {
 	Reply         interface{} // Reply reply from the function
 	Error         error       // Error if error occurs, it will be set
 	Done          chan *Call  // Done strobes when call is complete
+
+	Metadata map[string]string // Metadata 随请求发送的元数据
 }
 
 func (call *Call) done() {
@@ -93,7 +96,7 @@ func (c *Client) terminateCalls(err error) {
 	c.sending.Lock()
 	defer c.sending.Unlock()
 	c.mu.Lock()
-	defer c.mu.Lock()
+	defer c.mu.Unlock()
 	c.shutdown = true
 	for _, call := range c.pending {
 		call.Error = err
@@ -128,6 +131,8 @@ func (c *Client) receive() {
 			call.done()
 		}
 	}
+	// error occurs, so terminateCalls pending calls
+	c.terminateCalls(err)
 }
 
 // clientResult 创建client 结果
@@ -262,6 +267,7 @@ func (c *Client) send(call *Call) {
 	c.header.ServiceMethod = call.ServiceMethod
 	c.header.Seq = seq
 	c.header.Error = ""
+	c.header.Metadata = call.Metadata
 
 	// encode and send request
 	if err := c.cc.Write(&c.header, call.Args); err != nil {
@@ -275,24 +281,41 @@ func (c *Client) send(call *Call) {
 
 // Go invokes the function asynchronously. It returns the Call structure representing the invocation
 func (c *Client) Go(serviceMethod string, args, reply interface{}, done chan *Call) *Call {
+	call := newCall(serviceMethod, args, reply, done)
+	c.send(call)
+	return call
+}
+
+func newCall(serviceMethod string, args, reply interface{}, done chan *Call) *Call {
 	if done == nil {
 		done = make(chan *Call, 10)
 	} else if cap(done) == 0 {
 		log.Panic("rpc client: done channel is unbuffered")
 	}
-	call := &Call{
+	return &Call{
 		ServiceMethod: serviceMethod,
 		Args:          args,
 		Reply:         reply,
 		Done:          done,
 	}
-	c.send(call)
-	return call
 }
 
-// Call invokes the named function, waits for it to complete and returns its error status
-func (c *Client) Call(ctx context.Context, serviceMethod string, args, reply interface{}) error {
-	call := c.Go(serviceMethod, args, reply, make(chan *Call, 1))
+// Call invokes the named function, waits for it to complete and returns its error status.
+// The span in ctx is propagated to the server through the call metadata.
+func (c *Client) Call(ctx context.Context, serviceMethod string, args, reply interface{}) (err error) {
+	ctx, span := tracing.Start(ctx, serviceMethod)
+	span.SetAttribute("rpc.method", serviceMethod)
+	defer func() {
+		if err != nil {
+			span.SetError(err)
+		}
+		span.End()
+	}()
+
+	call := newCall(serviceMethod, args, reply, make(chan *Call, 1))
+	call.Metadata = make(map[string]string)
+	tracing.Inject(ctx, call.Metadata)
+	c.send(call)
 	select {
 	case <-ctx.Done():
 		c.removeCall(call.Seq)
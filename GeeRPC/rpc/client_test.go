@@ -8,6 +8,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/MarkRepo/Gee/GeeTrace/tracing"
 )
 
 func TestClient_dialTimeout(t *testing.T) {
@@ -71,19 +73,61 @@ func TestClient_Call(t *testing.T) {
 
 func TestXDial(t *testing.T) {
 	if runtime.GOOS == "linux" {
-		ch := make(chan struct{})
 		addr := "/tmp/geerpc.sock"
-		go func() {
-			_ = os.Remove(addr)
-			l, err := net.Listen("unix", addr)
-			if err != nil {
-				t.Fatal("failed to listen unix socket")
-			}
-			ch <- struct{}{}
-			Accept(l)
-		}()
-		<-ch
-		_, err := XDial("unix@" + addr)
+		_ = os.Remove(addr)
+		l, err := net.Listen("unix", addr)
+		if err != nil {
+			t.Fatal("failed to listen unix socket")
+		}
+		go Accept(l)
+		_, err = XDial("unix@" + addr)
 		_assert(err == nil, "failed to connect unix socket")
 	}
 }
+
+type Traced int
+
+func (t Traced) TraceID(ctx context.Context, _ int, reply *string) error {
+	sc, _ := tracing.SpanContextFromContext(ctx)
+	*reply = sc.TraceID.String()
+	return nil
+}
+
+func TestClient_CallTrace(t *testing.T) {
+	var traced Traced
+	server := NewServer()
+	_ = server.Register(&traced)
+	l, _ := net.Listen("tcp", ":0")
+	go server.Accept(l)
+
+	client, err := Dial("tcp", l.Addr().String())
+	_assert(err == nil, "failed to dial: %v", err)
+	defer func() { _ = client.Close() }()
+	ctx, span := tracing.Start(context.Background(), "root")
+	defer span.End()
+	var reply string
+	err = client.Call(ctx, "Traced.TraceID", 1, &reply)
+	_assert(err == nil && reply == span.SpanContext().TraceID.String(), "expect trace id propagated, got %q %v", reply, err)
+}
+
+func TestClient_ServerClose(t *testing.T) {
+	l, _ := net.Listen("tcp", ":0")
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		// 读取 Option 和请求后直接断开连接
+		_, _ = conn.Read(make([]byte, 1024))
+		_ = conn.Close()
+	}()
+
+	client, err := Dial("tcp", l.Addr().String())
+	_assert(err == nil, "failed to dial: %v", err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var reply int
+	err = client.Call(ctx, "Bar.Timeout", 1, &reply)
+	_assert(err != nil && ctx.Err() == nil, "expect pending call to fail once connection is closed, got %v", err)
+	_assert(!client.IsAvailable(), "client should not be available after connection is closed")
+}
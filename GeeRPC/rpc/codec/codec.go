@@ -8,6 +8,7 @@ type Header struct {
 	ServiceMethod string // format "Service.Method"
 	Seq           uint64 // sequence number chosen by client
 	Error         string
+	Metadata      map[string]string // Metadata 请求元数据，例如链路追踪的 traceparent，响应中为空
 }
 
 // Codec rpc 编解码器接口，client、server 共用
@@ -1,10 +1,13 @@
 package rpc
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"net"
 	"net/http"
@@ -14,6 +17,7 @@ import (
 	"time"
 
 	"github.com/MarkRepo/Gee/GeeRPC/rpc/codec"
+	"github.com/MarkRepo/Gee/GeeTrace/tracing"
 )
 
 const MagicNumber = 0x3bef5c // MagicNumber gee rpc 魔数
@@ -74,7 +78,8 @@ func (server *Server) ServeConn(conn io.ReadWriteCloser) {
 	}()
 
 	var opt Option
-	if err := json.NewDecoder(conn).Decode(&opt); err != nil {
+	dec := json.NewDecoder(conn)
+	if err := dec.Decode(&opt); err != nil {
 		log.Println("rpc server: options error: ", err)
 		return
 	}
@@ -90,7 +95,20 @@ func (server *Server) ServeConn(conn io.ReadWriteCloser) {
 		return
 	}
 
-	server.serveCodec(f(conn), opt.HandleTimeout)
+	// json.Decoder 可能已经预读了 Option 之后的请求数据，去掉 Option 末尾的换行后交给 codec
+	buffered, _ := ioutil.ReadAll(dec.Buffered())
+	buffered = bytes.TrimLeft(buffered, " \t\r\n")
+	server.serveCodec(f(&bufferedConn{r: io.MultiReader(bytes.NewReader(buffered), conn), ReadWriteCloser: conn}), opt.HandleTimeout)
+}
+
+// bufferedConn 先读取 json.Decoder 预读的数据，再读取 conn
+type bufferedConn struct {
+	io.ReadWriteCloser
+	r io.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
 }
 
 // invalidRequest is a placeHolder for response argv when error occurs
@@ -172,13 +190,24 @@ func (server *Server) sendResponse(cc codec.Codec, h *codec.Header, body interfa
 }
 
 // handleRequest 带超时处理，这里需要确保 sendResponse 仅调用一次，因此将整个过程拆分为 called 和 sent 两个阶段
+// 调用方通过 Metadata 传递的 traceparent 会作为服务端 span 的父 span
 func (server *Server) handleRequest(
 	cc codec.Codec, req *request, sending *sync.Mutex, wg *sync.WaitGroup, timeout time.Duration) {
 	defer wg.Done()
+	ctx, cancel := context.WithCancel(tracing.Extract(context.Background(), req.h.Metadata))
+	defer cancel()
+	req.h.Metadata = nil
+	ctx, span := tracing.Start(ctx, req.h.ServiceMethod)
+	span.SetAttribute("rpc.method", req.h.ServiceMethod)
+
 	called := make(chan struct{})
 	sent := make(chan struct{})
 	go func() {
-		err := req.svc.Call(req.mt, req.arg, req.reply)
+		err := req.svc.CallContext(ctx, req.mt, req.arg, req.reply)
+		if err != nil {
+			span.SetError(err)
+		}
+		span.End()
 		called <- struct{}{}
 		if err != nil {
 			req.h.Error = err.Error()
@@ -2,6 +2,7 @@
 package rpc
 
 import (
+	"context"
 	"go/ast"
 	"log"
 	"reflect"
@@ -18,12 +19,18 @@ import (
 // 更直观一些：
 // func (t *T) MethodName(argType T1, replyType *T2) error
 
+// 此外也支持第一个参数为 context.Context 的方法，context 携带调用方传递的链路追踪信息，服务端超时后会被取消：
+// func (t *T) MethodName(ctx context.Context, argType T1, replyType *T2) error
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
 // methodType  描述一个方法
 type methodType struct {
 	method    reflect.Method // method 方法的发射值
 	ArgType   reflect.Type   // ArgType 方法请求参数类型
 	ReplyType reflect.Type   // ReplyType 方法响应类型
 	numCalls  uint64         // numCalls 统计方法调用次数
+	withCtx   bool           // withCtx 方法第一个参数为 context.Context
 }
 
 // NumCalls 返回调用次数
@@ -82,13 +89,14 @@ func (s *service) registerMethods() {
 	for i := 0; i < s.typ.NumMethod(); i++ {
 		method := s.typ.Method(i)
 		mType := method.Type
-		if mType.NumIn() != 3 || mType.NumOut() != 1 {
+		withCtx := mType.NumIn() == 4 && mType.In(1) == contextType
+		if (mType.NumIn() != 3 && !withCtx) || mType.NumOut() != 1 {
 			continue
 		}
 		if mType.Out(0) != reflect.TypeOf((*error)(nil)).Elem() {
 			continue
 		}
-		argType, replyType := mType.In(1), mType.In(2)
+		argType, replyType := mType.In(mType.NumIn()-2), mType.In(mType.NumIn()-1)
 		if !isExportedOrBuildInType(argType) || !isExportedOrBuildInType(replyType) {
 			continue
 		}
@@ -96,6 +104,7 @@ func (s *service) registerMethods() {
 			method:    method,
 			ArgType:   argType,
 			ReplyType: replyType,
+			withCtx:   withCtx,
 		}
 		log.Printf("rpc server: register %s.%s\n", s.name, method.Name)
 	}
@@ -107,9 +116,18 @@ func isExportedOrBuildInType(t reflect.Type) bool {
 
 // Call 根据 methodType 和 args，reply调用方法
 func (s *service) Call(m *methodType, args, reply reflect.Value) error {
+	return s.CallContext(context.Background(), m, args, reply)
+}
+
+// CallContext 与 Call 相同，方法接收 context.Context 时传入 ctx
+func (s *service) CallContext(ctx context.Context, m *methodType, args, reply reflect.Value) error {
 	atomic.AddUint64(&m.numCalls, 1)
 	f := m.method.Func
-	returnValues := f.Call([]reflect.Value{s.svr, args, reply})
+	in := []reflect.Value{s.svr, args, reply}
+	if m.withCtx {
+		in = []reflect.Value{s.svr, reflect.ValueOf(ctx), args, reply}
+	}
+	returnValues := f.Call(in)
 	if errInter := returnValues[0].Interface(); errInter != nil {
 		return errInter.(error)
 	}
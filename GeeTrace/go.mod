@@ -0,0 +1,3 @@
+module github.com/MarkRepo/Gee/GeeTrace
+
+go 1.16
@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TraceID 16 字节的 trace 标识，同一条调用链上所有 span 共享
+type TraceID [16]byte
+
+// SpanID 8 字节的 span 标识
+type SpanID [8]byte
+
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+// FlagsSampled 表示调用方对该 trace 采样，未采样的 span 仍然会传递，但不会被导出
+const FlagsSampled byte = 0x01
+
+// SpanContext 跨进程传递的 span 信息
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Flags   byte
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags&FlagsSampled != 0
+}
+
+// TraceparentHeader is the name of the W3C trace context header
+const TraceparentHeader = "traceparent"
+
+var errInvalidTraceparent = errors.New("tracing: invalid traceparent")
+
+// ParseTraceparent parses a W3C traceparent header value, eg.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func ParseTraceparent(v string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, errInvalidTraceparent
+	}
+	// 版本 00 必须正好 4 段，更高的版本允许追加字段
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, errInvalidTraceparent
+	}
+	var flags [1]byte
+	if !decodeHex(sc.TraceID[:], parts[1]) || !decodeHex(sc.SpanID[:], parts[2]) || !decodeHex(flags[:], parts[3]) {
+		return sc, errInvalidTraceparent
+	}
+	sc.Flags = flags[0]
+	if !sc.IsValid() {
+		return sc, errInvalidTraceparent
+	}
+	return sc, nil
+}
+
+// decodeHex 只接受小写十六进制，长度必须与 dst 一致
+func decodeHex(dst []byte, s string) bool {
+	if len(s) != hex.EncodedLen(len(dst)) || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+// Traceparent formats sc as a W3C traceparent header value
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+func newTraceID() (id TraceID) {
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return
+}
+
+func newSpanID() (id SpanID) {
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return
+}
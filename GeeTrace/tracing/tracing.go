@@ -0,0 +1,225 @@
+// Package tracing 实现 W3C Trace Context 的传播和 span 记录，不依赖任何第三方库
+// 1. 入口(例如 HTTP 中间件)解析 traceparent，创建 span 并放入 context
+// 2. 出口(例如 RPC 客户端)根据 context 中的 span 生成 traceparent 传给下游
+// 3. 结束的 span 交给 Exporter 导出，InMemoryExporter 保存在内存中，用于测试
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Span 一次操作的记录
+type Span struct {
+	Name   string
+	Parent SpanID // Parent 父 span，根 span 为空
+	Start  time.Time
+
+	tracer *Tracer
+	sc     SpanContext
+
+	mu    sync.Mutex // protect following
+	end   time.Time
+	attrs map[string]string
+	err   error
+}
+
+// SpanContext returns the propagated identity of the span
+func (s *Span) SpanContext() SpanContext {
+	return s.sc
+}
+
+// SetAttribute records a key value pair on the span
+func (s *Span) SetAttribute(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attrs == nil {
+		s.attrs = make(map[string]string)
+	}
+	s.attrs[key] = value
+}
+
+// Attributes returns a copy of the attributes of the span
+func (s *Span) Attributes() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attrs := make(map[string]string, len(s.attrs))
+	for k, v := range s.attrs {
+		attrs[k] = v
+	}
+	return attrs
+}
+
+// SetError marks the span as failed
+func (s *Span) SetError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// Err returns the error set by SetError
+func (s *Span) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// EndTime returns the time End was called, it is zero if the span is not ended
+func (s *Span) EndTime() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.end
+}
+
+// End finishes the span and exports it if sampled, calls after the first one are ignored
+func (s *Span) End() {
+	s.mu.Lock()
+	if !s.end.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.end = time.Now()
+	s.mu.Unlock()
+	if !s.sc.IsSampled() {
+		return
+	}
+	if exporter := s.tracer.Exporter(); exporter != nil {
+		exporter.Export(s)
+	}
+}
+
+// Exporter receives finished spans
+type Exporter interface {
+	Export(span *Span)
+}
+
+// Tracer creates spans and exports them when they end
+type Tracer struct {
+	mu       sync.RWMutex // protects exporter
+	exporter Exporter
+}
+
+// NewTracer creates a Tracer, spans are not exported if exporter is nil
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// Exporter returns the exporter of t
+func (t *Tracer) Exporter() Exporter {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.exporter
+}
+
+// SetExporter replaces the exporter of t, it is safe to call while spans are being exported
+func (t *Tracer) SetExporter(exporter Exporter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.exporter = exporter
+}
+
+// DefaultTracer is used by Start, it does not export spans until SetExporter is called
+var DefaultTracer = NewTracer(nil)
+
+// SetExporter sets the exporter of DefaultTracer
+func SetExporter(exporter Exporter) {
+	DefaultTracer.SetExporter(exporter)
+}
+
+// Start starts a span using DefaultTracer
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	return DefaultTracer.Start(ctx, name)
+}
+
+// Start starts a span as a child of the span or the remote span context in ctx,
+// a new trace is started if there is neither. The returned context carries the new span.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	s := &Span{Name: name, Start: time.Now(), tracer: t}
+	if parent, ok := SpanContextFromContext(ctx); ok {
+		s.sc = SpanContext{TraceID: parent.TraceID, Flags: parent.Flags}
+		s.Parent = parent.SpanID
+	} else {
+		s.sc = SpanContext{TraceID: newTraceID(), Flags: FlagsSampled}
+	}
+	s.sc.SpanID = newSpanID()
+	return ContextWithSpan(ctx, s), s
+}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span in ctx, or nil if there is none
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// ContextWithRemoteSpanContext returns a copy of ctx carrying sc received from the caller,
+// spans started from the returned context are children of sc
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context of the span in ctx, or the remote span context
+// if there is no span
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	if ctx == nil {
+		return SpanContext{}, false
+	}
+	if s := SpanFromContext(ctx); s != nil {
+		return s.sc, true
+	}
+	sc, ok := ctx.Value(remoteKey{}).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+// Inject writes the traceparent of the span context in ctx to carrier, it does nothing if there is none
+func Inject(ctx context.Context, carrier map[string]string) {
+	if sc, ok := SpanContextFromContext(ctx); ok {
+		carrier[TraceparentHeader] = sc.Traceparent()
+	}
+}
+
+// Extract returns a copy of ctx carrying the remote span context in carrier, ctx is returned
+// unchanged if carrier has no valid traceparent
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	sc, err := ParseTraceparent(carrier[TraceparentHeader])
+	if err != nil {
+		return ctx
+	}
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// InMemoryExporter keeps finished spans in memory, it is mainly used in tests
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// Export implements Exporter
+func (e *InMemoryExporter) Export(span *Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the exported spans in the order they ended
+func (e *InMemoryExporter) Spans() []*Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*Span(nil), e.spans...)
+}
+
+// Reset removes all exported spans
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
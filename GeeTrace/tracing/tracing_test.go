@@ -0,0 +1,86 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	v := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceparent(v)
+	if err != nil || !sc.IsSampled() || sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("failed to parse traceparent: %+v, %v", sc, err)
+	}
+	if sc.Traceparent() != v {
+		t.Fatalf("expect %s, got %s", v, sc.Traceparent())
+	}
+	for _, invalid := range []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceparent(invalid); err == nil {
+			t.Fatalf("expect error for %q", invalid)
+		}
+	}
+}
+
+func TestTracer(t *testing.T) {
+	exporter := &InMemoryExporter{}
+	tracer := NewTracer(exporter)
+
+	remote, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := ContextWithRemoteSpanContext(context.Background(), remote)
+	ctx, parent := tracer.Start(ctx, "parent")
+	carrier := make(map[string]string)
+	Inject(ctx, carrier)
+	_, child := tracer.Start(Extract(context.Background(), carrier), "child")
+	child.End()
+	parent.End()
+	parent.End()
+
+	spans := exporter.Spans()
+	if len(spans) != 2 || spans[0] != child || spans[1] != parent {
+		t.Fatalf("expect child and parent exported once, got %d spans", len(spans))
+	}
+	if parent.SpanContext().TraceID != remote.TraceID || parent.Parent != remote.SpanID {
+		t.Fatal("parent should continue the remote trace")
+	}
+	if child.SpanContext().TraceID != remote.TraceID || child.Parent != parent.SpanContext().SpanID {
+		t.Fatal("child should be a child of parent")
+	}
+
+	exporter.Reset()
+	unsampled := remote
+	unsampled.Flags = 0
+	_, s := tracer.Start(ContextWithRemoteSpanContext(context.Background(), unsampled), "unsampled")
+	s.End()
+	if len(exporter.Spans()) != 0 {
+		t.Fatal("unsampled span should not be exported")
+	}
+}
+
+func TestSetExporter(t *testing.T) {
+	tracer := NewTracer(nil)
+	_, s := tracer.Start(context.Background(), "dropped")
+	s.End()
+
+	exporter := &InMemoryExporter{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tracer.SetExporter(exporter)
+	}()
+	for i := 0; i < 10; i++ {
+		_, s := tracer.Start(context.Background(), "span")
+		s.End()
+	}
+	<-done
+	_, s = tracer.Start(context.Background(), "exported")
+	s.End()
+	if spans := exporter.Spans(); len(spans) == 0 || spans[len(spans)-1].Name != "exported" {
+		t.Fatalf("expect spans exported after SetExporter, got %d spans", len(spans))
+	}
+}